@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	cli "nned/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the nned configuration",
+	}
+	configShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration and where each value came from",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			cli.ShowConfig(os.Stdout, resolved)
+		},
+	}
+)
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	cli "nned/internal/cli"
+	c "nned/internal/common"
+	"nned/internal/digest"
+	mon "nned/internal/monitor"
+	"nned/internal/rules"
+	"nned/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestOptions struct {
+		Since     time.Duration
+		Format    string
+		GroupBy   string
+		All       bool
+		Title     string
+		Templates string
+	}
+	digestCmd = &cobra.Command{
+		Use:          "digest",
+		Short:        "Fetch every feed once and write a digest of the recent articles to stdout",
+		Args:         cli.Validate(&config, &err),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !slices.Contains(digest.Formats, digestOptions.Format) {
+				return fmt.Errorf("unknown format %q, expected one of %s", digestOptions.Format, strings.Join(digest.Formats, ", "))
+			}
+			if !slices.Contains(digest.GroupBy, digestOptions.GroupBy) {
+				return fmt.Errorf("unknown grouping %q, expected one of %s", digestOptions.GroupBy, strings.Join(digest.GroupBy, ", "))
+			}
+			until := time.Now()
+			since := until.Add(-digestOptions.Since)
+
+			engine, err := rules.Compile(config.Rules)
+			if err != nil {
+				return err
+			}
+			st, err := store.Open(dep.Fs, store.DefaultPath(), earliest(since, config.LastDate))
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			monitor, _ := mon.NewMonitor(mon.Config{
+				Feeds:    config.NewsFeeds,
+				LastDate: since,
+				Store:    st,
+				Rules:    engine,
+				Cluster:  config.Cluster,
+			})
+			_, err = monitor.Once()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+
+			articles := make([]c.Article, 0)
+			for _, a := range monitor.StoredArticles() {
+				if r, _ := st.Get(store.Key(a)); digestOptions.All || !r.Read {
+					articles = append(articles, a)
+				}
+			}
+			d, err := digest.New(digestOptions.Title, articles, digestOptions.GroupBy, since, until, monitor.Stories())
+			if err != nil {
+				return err
+			}
+			return digest.Render(os.Stdout, dep.Fs, digestOptions.Templates, digestOptions.Format, d)
+		},
+	}
+)
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func init() {
+	digestCmd.Flags().DurationVar(&digestOptions.Since, "since", 24*time.Hour, "period to cover, e.g. 168h for a week")
+	digestCmd.Flags().StringVarP(&digestOptions.Format, "format", "f", digest.FormatMarkdown, "output format: "+strings.Join(digest.Formats, ", "))
+	digestCmd.Flags().StringVar(&digestOptions.GroupBy, "group-by", digest.GroupByFeed, "group articles by: "+strings.Join(digest.GroupBy, ", "))
+	digestCmd.Flags().BoolVar(&digestOptions.All, "all", false, "include articles that were already read")
+	digestCmd.Flags().StringVar(&digestOptions.Title, "title", "nned digest", "title of the digest")
+	digestCmd.Flags().StringVar(&digestOptions.Templates, "templates", digest.DefaultTemplateDir(), "directory with digest.<format>.tmpl templates that replace the built-in ones")
+	rootCmd.AddCommand(digestCmd)
+}
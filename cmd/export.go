@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	cli "nned/internal/cli"
+	mon "nned/internal/monitor"
+	"nned/internal/planet"
+	"nned/internal/rules"
+	"nned/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFeedOptions struct {
+		Format   string
+		Category string
+		Title    string
+		Link     string
+	}
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export feeds and articles",
+	}
+	exportOPMLCmd = &cobra.Command{
+		Use:          "opml",
+		Short:        "Write the configured feeds as an OPML 2.0 document to stdout",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return cli.ExportOPML(os.Stdout, config)
+		},
+	}
+	exportFeedCmd = &cobra.Command{
+		Use:          "feed",
+		Short:        "Fetch every feed once and write the articles as a single feed to stdout",
+		Args:         cli.Validate(&config, &err),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !slices.Contains(planet.Formats, exportFeedOptions.Format) {
+				return fmt.Errorf("unknown format %q, expected one of %s", exportFeedOptions.Format, strings.Join(planet.Formats, ", "))
+			}
+			if exportFeedOptions.Format == planet.FormatRSS && exportFeedOptions.Link == "" {
+				return fmt.Errorf("--link is required with --format %s", planet.FormatRSS)
+			}
+			feeds, err := cli.FilterCategory(config.NewsFeeds, exportFeedOptions.Category)
+			if err != nil {
+				return err
+			}
+
+			engine, err := rules.Compile(config.Rules)
+			if err != nil {
+				return err
+			}
+			st, err := store.Open(dep.Fs, store.DefaultPath(), config.LastDate)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			monitor, _ := mon.NewMonitor(mon.Config{
+				Feeds:    feeds,
+				LastDate: config.LastDate,
+				Store:    st,
+				Rules:    engine,
+				Cluster:  config.Cluster,
+			})
+			articles, err := monitor.Once()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			meta := planet.Meta{
+				Title: exportFeedOptions.Title,
+				Link:  exportFeedOptions.Link,
+			}
+			return planet.Write(os.Stdout, exportFeedOptions.Format, meta, planet.Merge(articles, monitor.Stories()))
+		},
+	}
+)
+
+func init() {
+	exportFeedCmd.Flags().StringVarP(&exportFeedOptions.Format, "format", "f", planet.FormatAtom, "output format: "+strings.Join(planet.Formats, ", "))
+	exportFeedCmd.Flags().StringVar(&exportFeedOptions.Category, "category", "", "only include feeds of this category")
+	exportFeedCmd.Flags().StringVar(&exportFeedOptions.Title, "title", "nned", "title of the feed")
+	exportFeedCmd.Flags().StringVar(&exportFeedOptions.Link, "link", "", "URL the feed will be published at, required for rss")
+	exportCmd.AddCommand(exportOPMLCmd)
+	exportCmd.AddCommand(exportFeedCmd)
+	rootCmd.AddCommand(exportCmd)
+}
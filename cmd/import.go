@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	cli "nned/internal/cli"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import feeds into the config file",
+	}
+	importOPMLCmd = &cobra.Command{
+		Use:          "opml <file>",
+		Short:        "Merge the feeds of an OPML file into the config file",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			handle, err := dep.Fs.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("unable to open opml: %w", err)
+			}
+			defer handle.Close()
+
+			path, added, skipped, err := cli.ImportOPML(dep, configPath, handle)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: added %d feed(s), skipped %d duplicate(s)\n", path, added, skipped)
+			return nil
+		},
+	}
+)
+
+func init() {
+	importCmd.AddCommand(importOPMLCmd)
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	cli "nned/internal/cli"
+	mon "nned/internal/monitor"
+	"nned/internal/rules"
+	"nned/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listOptions struct {
+		Format string
+		Since  time.Duration
+		Feeds  []string
+	}
+	listCmd = &cobra.Command{
+		Use:          "list",
+		Short:        "Fetch every feed once and print the articles",
+		Args:         cli.Validate(&config, &err),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !slices.Contains(cli.ListFormats, listOptions.Format) {
+				return fmt.Errorf("unknown format %q, expected one of %s", listOptions.Format, strings.Join(cli.ListFormats, ", "))
+			}
+			feeds, err := cli.FilterFeeds(config.NewsFeeds, listOptions.Feeds)
+			if err != nil {
+				return err
+			}
+			lastDate := config.LastDate
+			if listOptions.Since > 0 {
+				lastDate = time.Now().Add(-listOptions.Since)
+			}
+
+			engine, err := rules.Compile(config.Rules)
+			if err != nil {
+				return err
+			}
+			st, err := store.Open(dep.Fs, store.DefaultPath(), config.LastDate)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			monitor, _ := mon.NewMonitor(mon.Config{
+				Feeds:    feeds,
+				LastDate: lastDate,
+				Store:    st,
+				Rules:    engine,
+				Cluster:  config.Cluster,
+			})
+			articles, err := monitor.Once()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return cli.WriteArticles(os.Stdout, listOptions.Format, articles, monitor.Stories())
+		},
+	}
+)
+
+func init() {
+	listCmd.Flags().StringVarP(&listOptions.Format, "format", "f", "plain", "output format: "+strings.Join(cli.ListFormats, ", "))
+	listCmd.Flags().DurationVar(&listOptions.Since, "since", 0, "only list articles newer than this, e.g. 24h (default --last-date)")
+	listCmd.Flags().StringSliceVar(&listOptions.Feeds, "feed", nil, "only fetch feeds with this title or URL (repeatable)")
+	rootCmd.AddCommand(listCmd)
+}
@@ -17,6 +17,7 @@ var (
 	configPath string
 	ctx        c.Context
 	config     c.Config
+	resolved   cli.Resolved
 	options    cli.Options
 	dep        c.Dependencies
 	err        error
@@ -26,7 +27,7 @@ var (
 		Short:   "A simple RSS feed reader",
 		PreRun:  initContext,
 		Args:    cli.Validate(&config, &err),
-		Run:     cli.Run(ui.Start(&dep, &ctx)),
+		Run:     cli.Run(ui.Start(&dep, &ctx, watchConfig)),
 	}
 )
 
@@ -40,19 +41,24 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.Flags().StringVar(&configPath, "config", "", "config file (default is $HOME/.nned.yaml)")
-	rootCmd.Flags().StringVarP(&options.Feeds, "feeds", "n", "", "comma separated list of rss news feeds")
-	rootCmd.Flags().IntVarP(&options.RefreshInterval, "interval", "i", 30, "refresh interval in seconds")
-	rootCmd.Flags().TimeVarP(&options.LastDate, "last-date", "l", time.Now().Add(-time.Hour*time.Duration(24*7)), []string{"2006-01-02", "2006-01-02 15:04:05"}, "oldest date to fetch news")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file (default is $HOME/.nned.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&options.Feeds, "feeds", "n", "", "comma separated list of rss news feeds")
+	rootCmd.PersistentFlags().IntVarP(&options.RefreshInterval, "interval", "i", 0, "refresh interval in seconds (default 30)")
+	rootCmd.PersistentFlags().TimeVarP(&options.LastDate, "last-date", "l", time.Time{}, c.DateFormats, "oldest date to fetch news (default 7 days ago)")
 }
 
 func initConfig() {
 	dep = cli.GetDependencies()
-	config, err = cli.GetConfig(dep, configPath)
+	resolved, err = cli.ResolveConfig(dep, configPath, options)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	config = resolved.Config
+}
+
+func watchConfig(onChange func(c.Config), onError func(error)) (func() error, error) {
+	return cli.WatchConfig(dep, resolved.Path, options, onChange, onError)
 }
 
 func initContext(_ *cobra.Command, _ []string) {
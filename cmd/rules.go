@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	cli "nned/internal/cli"
+	"nned/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesCmd = &cobra.Command{
+		Use:   "rules",
+		Short: "Work with the rules of the config",
+	}
+	rulesTestCmd = &cobra.Command{
+		Use:          "test <rule>",
+		Short:        "Dry-run a rule against the stored articles",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			st, err := store.New(dep.Fs, store.DefaultPath())
+			if err != nil {
+				return err
+			}
+			return cli.TestRule(os.Stdout, st, config, args[0])
+		},
+	}
+)
+
+func init() {
+	rulesCmd.AddCommand(rulesTestCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
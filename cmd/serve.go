@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cli "nned/internal/cli"
+	c "nned/internal/common"
+	mon "nned/internal/monitor"
+	feedscraper "nned/internal/monitor/feed-scraper"
+	"nned/internal/monitor/fulltext"
+	"nned/internal/rules"
+	"nned/internal/server"
+	"nned/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveOptions struct {
+		Addr string
+	}
+	serveCmd = &cobra.Command{
+		Use:          "serve",
+		Short:        "Poll the feeds and serve the articles over HTTP",
+		Args:         cli.Validate(&config, &err),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			engine, err := rules.Compile(config.Rules)
+			if err != nil {
+				return err
+			}
+			st, err := store.Open(dep.Fs, store.DefaultPath(), config.LastDate)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			monitor, _ := mon.NewMonitor(mon.Config{
+				RefreshInterval: config.RefreshInterval,
+				MaxBackoff:      config.MaxBackoff,
+				Feeds:           config.NewsFeeds,
+				LastDate:        config.LastDate,
+				Store:           st,
+				Rules:           engine,
+				Cluster:         config.Cluster,
+				Fs:              dep.Fs,
+				CachePath:       feedscraper.DefaultCachePath(),
+				FullTextDir:     fulltext.DefaultCacheDir(),
+			})
+			srv := server.New(monitor, st)
+			srv.Configure(serverConfig(config))
+			srv.SetOnError(func(err error) {
+				fmt.Fprintln(os.Stderr, err)
+			})
+			err = monitor.SetOnUpdate(mon.ConfigUpdateFunc{
+				OnUpdateArticle: func(article c.Article, _ int) {
+					srv.Publish(article)
+				},
+				OnError: func(err error) {
+					fmt.Fprintln(os.Stderr, err)
+				},
+			})
+			if err != nil {
+				return err
+			}
+			monitor.Start()
+			defer monitor.Stop()
+
+			stopWatch, err := watchConfig(
+				func(config c.Config) {
+					engine, err := rules.Compile(config.Rules)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, fmt.Errorf("config not reloaded: %w", err))
+						return
+					}
+					monitor.Reconfigure(mon.Config{
+						RefreshInterval: config.RefreshInterval,
+						MaxBackoff:      config.MaxBackoff,
+						Feeds:           config.NewsFeeds,
+						LastDate:        config.LastDate,
+						Rules:           engine,
+						Cluster:         config.Cluster,
+					})
+					srv.Configure(serverConfig(config))
+				},
+				func(err error) {
+					fmt.Fprintln(os.Stderr, err)
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("unable to watch config: %w", err)
+			}
+			defer stopWatch()
+
+			httpServer := &http.Server{
+				Addr:              serveOptions.Addr,
+				Handler:           srv,
+				ReadHeaderTimeout: 10 * time.Second,
+				BaseContext:       func(_ net.Listener) context.Context { return ctx },
+			}
+			errs := make(chan error, 1)
+			go func() {
+				errs <- httpServer.ListenAndServe()
+			}()
+			fmt.Fprintf(os.Stderr, "listening on http://%s\n", serveOptions.Addr)
+
+			select {
+			case err = <-errs:
+			case <-ctx.Done():
+				shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				err = httpServer.Shutdown(shutdown)
+			}
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+	}
+)
+
+// serverConfig defaults the base URL of the served feeds to the address the
+// server listens on.
+func serverConfig(config c.Config) c.Config {
+	if config.Sync.BaseURL != "" {
+		return config
+	}
+	host, port, err := net.SplitHostPort(serveOptions.Addr)
+	if err != nil {
+		host, port = serveOptions.Addr, ""
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	config.Sync.BaseURL = "http://" + host
+	return config
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveOptions.Addr, "addr", "127.0.0.1:7777", "address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,53 @@
+package canonical
+
+import (
+	"net/url"
+	"strings"
+)
+
+var trackingParams = map[string]bool{
+	"fbclid":   true,
+	"gclid":    true,
+	"dclid":    true,
+	"msclkid":  true,
+	"yclid":    true,
+	"igshid":   true,
+	"mc_cid":   true,
+	"mc_eid":   true,
+	"mkt_tok":  true,
+	"_hsenc":   true,
+	"_hsmi":    true,
+	"ref":      true,
+	"ref_src":  true,
+	"ref_url":  true,
+	"cmpid":    true,
+	"ocid":     true,
+	"spm":      true,
+	"share":    true,
+	"sharesrc": true,
+}
+
+// Link normalizes a link so that the same page reached through
+// different campaigns compares equal: the scheme and host are lowercased,
+// the fragment is dropped and tracking parameters are removed.
+func Link(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	for param := range query {
+		name := strings.ToLower(param)
+		if strings.HasPrefix(name, "utm_") || trackingParams[name] {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,27 @@
+package canonical
+
+import "testing"
+
+func TestLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://example.com/a", "https://example.com/a"},
+		{"  https://example.com/a  ", "https://example.com/a"},
+		{"HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"https://example.com/a#comments", "https://example.com/a"},
+		{"https://example.com/a?utm_source=rss&utm_medium=feed", "https://example.com/a"},
+		{"https://example.com/a?UTM_Campaign=x&id=3", "https://example.com/a?id=3"},
+		{"https://example.com/a?fbclid=1&ref=hn&page=2", "https://example.com/a?page=2"},
+		{"https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"/relative?utm_source=x", "/relative?utm_source=x"},
+		{"not a url", "not a url"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Link(tt.link); got != tt.want {
+			t.Errorf("Link(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
@@ -46,7 +47,8 @@ func Validate(config *c.Config, prevErr *error) func(*cobra.Command, []string) e
 
 func GetDependencies() c.Dependencies {
 	return c.Dependencies{
-		Fs: afero.NewOsFs(),
+		Fs:        afero.NewOsFs(),
+		LookupEnv: os.LookupEnv,
 	}
 }
 
@@ -71,35 +73,27 @@ func GetContext(d c.Dependencies, config c.Config) (c.Context, error) {
 	return context, err
 }
 
-func readConfig(fs afero.Fs, configPathOption string) (c.Config, error) {
+func readConfig(fs afero.Fs, configPathOption string) (c.Config, string, error) {
 	var config c.Config
 	configPath, err := getConfigPath(fs, configPathOption)
+	if isConfigNotFound(err) {
+		return config, "", nil
+	}
 	if err != nil {
-		return config, nil
+		return config, "", err
 	}
 
 	handle, err := fs.Open(configPath)
-	defer handle.Close()
 	if err != nil {
-		return config, fmt.Errorf("invalid config: %w", err)
+		return config, "", fmt.Errorf("invalid config: %w", err)
 	}
+	defer handle.Close()
 
 	err = yaml.NewDecoder(handle).Decode(&config)
-	if err != nil {
-		return config, fmt.Errorf("invalid config: %w", err)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return config, "", fmt.Errorf("invalid config: %w", err)
 	}
-	return config, nil
-}
-
-func GetConfig(d c.Dependencies, configPath string) (c.Config, error) {
-	config, err := readConfig(d.Fs, configPath)
-	if err != nil {
-		return c.Config{}, err
-	}
-
-	config.RefreshInterval = getRefreshInterval(0, config.RefreshInterval)
-	config.Debug = false
-	return config, nil
+	return config, configPath, nil
 }
 
 func getConfigPath(fs afero.Fs, configPathOption string) (string, error) {
@@ -123,14 +117,11 @@ func getConfigPath(fs afero.Fs, configPathOption string) (string, error) {
 	return v.ConfigFileUsed(), nil
 }
 
-func getRefreshInterval(optionsRefreshInverval int, configRefreshInterval int) int {
-	if optionsRefreshInverval > 0 {
-		return optionsRefreshInverval
-	}
-	if configRefreshInterval > 0 {
-		return configRefreshInterval
-	}
-	return 30
+// isConfigNotFound reports whether no config file was found in the default
+// locations, which is not an error since feeds can be passed as flags.
+func isConfigNotFound(err error) bool {
+	var notFound viper.ConfigFileNotFoundError
+	return errors.As(err, &notFound)
 }
 
 func getLogger(d c.Dependencies) (*log.Logger, error) {
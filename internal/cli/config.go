@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"nned/internal/cluster"
+	c "nned/internal/common"
+	"nned/internal/notify"
+	"nned/internal/rules"
+)
+
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+const envPrefix = "NNED_"
+
+// Resolved is the effective configuration together with the layer each
+// value was taken from. Flags override environment variables, which
+// override the config file, which overrides the defaults.
+type Resolved struct {
+	Config  c.Config
+	Path    string
+	Sources map[string]Source
+}
+
+func ResolveConfig(d c.Dependencies, configPath string, options Options) (Resolved, error) {
+	file, path, err := readConfig(d.Fs, configPath)
+	if err != nil {
+		return Resolved{}, err
+	}
+
+	r := Resolved{
+		Config:  file,
+		Path:    path,
+		Sources: make(map[string]Source),
+	}
+
+	r.Config.RefreshInterval = 30
+	r.Sources["interval"] = SourceDefault
+	if file.RefreshInterval > 0 {
+		r.Config.RefreshInterval = file.RefreshInterval
+		r.Sources["interval"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "INTERVAL"); ok {
+		interval, err := strconv.Atoi(v)
+		if err != nil || interval <= 0 {
+			return Resolved{}, fmt.Errorf("invalid %sINTERVAL: %q", envPrefix, v)
+		}
+		r.Config.RefreshInterval = interval
+		r.Sources["interval"] = SourceEnv
+	}
+	if options.RefreshInterval > 0 {
+		r.Config.RefreshInterval = options.RefreshInterval
+		r.Sources["interval"] = SourceFlag
+	}
+
+	r.Config.LastDate = time.Now().Add(-time.Hour * time.Duration(24*7))
+	r.Sources["last-date"] = SourceDefault
+	if !file.LastDate.IsZero() {
+		r.Config.LastDate = file.LastDate
+		r.Sources["last-date"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "LAST_DATE"); ok {
+		lastDate, err := c.ParseDate(v)
+		if err != nil {
+			return Resolved{}, fmt.Errorf("invalid %sLAST_DATE: %w", envPrefix, err)
+		}
+		r.Config.LastDate = lastDate
+		r.Sources["last-date"] = SourceEnv
+	}
+	if !options.LastDate.IsZero() {
+		r.Config.LastDate = options.LastDate
+		r.Sources["last-date"] = SourceFlag
+	}
+
+	r.Config.MaxBackoff = 3600
+	r.Sources["max-backoff"] = SourceDefault
+	if file.MaxBackoff > 0 {
+		r.Config.MaxBackoff = file.MaxBackoff
+		r.Sources["max-backoff"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "MAX_BACKOFF"); ok {
+		maxBackoff, err := strconv.Atoi(v)
+		if err != nil || maxBackoff <= 0 {
+			return Resolved{}, fmt.Errorf("invalid %sMAX_BACKOFF: %q", envPrefix, v)
+		}
+		r.Config.MaxBackoff = maxBackoff
+		r.Sources["max-backoff"] = SourceEnv
+	}
+
+	r.Sources["browser"] = SourceDefault
+	if file.Browser != "" {
+		r.Sources["browser"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "BROWSER"); ok {
+		r.Config.Browser = v
+		r.Sources["browser"] = SourceEnv
+	}
+
+	r.Sources["cluster"] = SourceDefault
+	if file.Cluster.Enabled {
+		r.Sources["cluster"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "CLUSTER"); ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Resolved{}, fmt.Errorf("invalid %sCLUSTER: %q", envPrefix, v)
+		}
+		r.Config.Cluster.Enabled = enabled
+		r.Sources["cluster"] = SourceEnv
+	}
+	if r.Config.Cluster.Threshold == 0 {
+		r.Config.Cluster.Threshold = cluster.DefaultThreshold
+	}
+	if r.Config.Cluster.Threshold < 0 || r.Config.Cluster.Threshold > 1 {
+		return Resolved{}, fmt.Errorf("invalid cluster threshold %v: must be between 0 and 1", r.Config.Cluster.Threshold)
+	}
+
+	r.Sources["rules"] = SourceDefault
+	if len(file.Rules) > 0 {
+		r.Sources["rules"] = SourceFile
+	}
+	_, err = rules.Compile(r.Config.Rules)
+	if err != nil {
+		return Resolved{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	r.Sources["notify"] = SourceDefault
+	if file.Notify.Enabled {
+		r.Sources["notify"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "NOTIFY"); ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Resolved{}, fmt.Errorf("invalid %sNOTIFY: %q", envPrefix, v)
+		}
+		r.Config.Notify.Enabled = enabled
+		r.Sources["notify"] = SourceEnv
+	}
+	err = notify.Validate(r.Config.Notify)
+	if err != nil {
+		return Resolved{}, fmt.Errorf("invalid config: notify: %w", err)
+	}
+
+	r.Sources["sync"] = SourceDefault
+	if file.Sync.Username != "" || file.Sync.APIKey != "" {
+		r.Sources["sync"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "SYNC_USERNAME"); ok {
+		r.Config.Sync.Username = v
+		r.Sources["sync"] = SourceEnv
+	}
+	if v, ok := lookupEnv(d, "SYNC_API_KEY"); ok {
+		r.Config.Sync.APIKey = v
+		r.Sources["sync"] = SourceEnv
+	}
+	if (r.Config.Sync.Username == "") != (r.Config.Sync.APIKey == "") {
+		return Resolved{}, fmt.Errorf("invalid config: sync needs both a username and an api-key")
+	}
+	if base := r.Config.Sync.BaseURL; base != "" {
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Resolved{}, fmt.Errorf("invalid config: sync base-url %q must be an http or https URL", base)
+		}
+	}
+
+	r.Sources["debug"] = SourceDefault
+	if file.Debug {
+		r.Sources["debug"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "DEBUG"); ok {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return Resolved{}, fmt.Errorf("invalid %sDEBUG: %q", envPrefix, v)
+		}
+		r.Config.Debug = debug
+		r.Sources["debug"] = SourceEnv
+	}
+
+	r.Sources["feeds"] = SourceDefault
+	if len(file.NewsFeeds) > 0 {
+		r.Sources["feeds"] = SourceFile
+	}
+	if v, ok := lookupEnv(d, "FEEDS"); ok {
+		r.Config.NewsFeeds = parseFeeds(v)
+		r.Sources["feeds"] = SourceEnv
+	}
+	if options.Feeds != "" {
+		r.Config.NewsFeeds = parseFeeds(options.Feeds)
+		r.Sources["feeds"] = SourceFlag
+	}
+
+	return r, nil
+}
+
+func ShowConfig(w io.Writer, r Resolved) {
+	path := r.Path
+	if path == "" {
+		path = "none"
+	}
+	fmt.Fprintf(w, "config file: %s\n\n", path)
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "interval:", strconv.Itoa(r.Config.RefreshInterval), r.Sources["interval"])
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "max-backoff:", strconv.Itoa(r.Config.MaxBackoff), r.Sources["max-backoff"])
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "last-date:", r.Config.LastDate.Format(c.DateFormats[1]), r.Sources["last-date"])
+	browser := r.Config.Browser
+	if browser == "" {
+		browser = "$BROWSER or xdg-open"
+	}
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "browser:", browser, r.Sources["browser"])
+	clustering := "off"
+	if r.Config.Cluster.Enabled {
+		clustering = fmt.Sprintf("on, threshold %.2f", r.Config.Cluster.Threshold)
+	}
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "cluster:", clustering, r.Sources["cluster"])
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "rules:", strconv.Itoa(len(r.Config.Rules)), r.Sources["rules"])
+	notifications := "off"
+	if r.Config.Notify.Enabled {
+		method := r.Config.Notify.Method
+		if method == "" {
+			method = notify.MethodBell
+		}
+		notifications = "on, " + method
+	}
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "notify:", notifications, r.Sources["notify"])
+	sync := "off"
+	if r.Config.Sync.Username != "" {
+		sync = "fever, user " + r.Config.Sync.Username
+	}
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "sync:", sync, r.Sources["sync"])
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "debug:", strconv.FormatBool(r.Config.Debug), r.Sources["debug"])
+	fmt.Fprintf(w, "%-12s %-26s %s\n", "feeds:", strconv.Itoa(len(r.Config.NewsFeeds)), r.Sources["feeds"])
+	for _, feed := range r.Config.NewsFeeds {
+		line := "  - " + feed.Url
+		if feed.Title != "" {
+			line += " (" + feed.Title + ")"
+		}
+		if feed.Interval > 0 {
+			line += fmt.Sprintf(" every %ds", feed.Interval)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func lookupEnv(d c.Dependencies, key string) (string, bool) {
+	if d.LookupEnv == nil {
+		return "", false
+	}
+	v, ok := d.LookupEnv(envPrefix + key)
+	if !ok || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+func parseFeeds(s string) []c.Feed {
+	feeds := make([]c.Feed, 0)
+	for _, url := range strings.Split(s, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		feeds = append(feeds, c.Feed{Url: url})
+	}
+	return feeds
+}
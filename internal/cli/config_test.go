@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/afero"
+)
+
+const testConfig = `
+interval: 60
+max-backoff: 600
+last-date: 2024-01-01T00:00:00Z
+feeds:
+  - url: https://go.dev/blog/feed.atom
+    title: Go
+`
+
+func deps(t *testing.T, config string, env map[string]string) c.Dependencies {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	if config != "" {
+		err := afero.WriteFile(fs, "/nned.yaml", []byte(config), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c.Dependencies{
+		Fs: fs,
+		LookupEnv: func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		},
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	flagDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		config  string
+		env     map[string]string
+		options Options
+		check   func(t *testing.T, r Resolved)
+	}{
+		{
+			name:   "defaults",
+			config: "feeds: [{url: https://example.com/feed}]",
+			check: func(t *testing.T, r Resolved) {
+				if r.Config.RefreshInterval != 30 || r.Sources["interval"] != SourceDefault {
+					t.Errorf("interval = %d from %s", r.Config.RefreshInterval, r.Sources["interval"])
+				}
+				if r.Config.MaxBackoff != 3600 || r.Sources["max-backoff"] != SourceDefault {
+					t.Errorf("max-backoff = %d from %s", r.Config.MaxBackoff, r.Sources["max-backoff"])
+				}
+				if age := time.Since(r.Config.LastDate); age < 7*24*time.Hour-time.Minute || age > 7*24*time.Hour+time.Minute {
+					t.Errorf("last-date = %v, want a week ago", r.Config.LastDate)
+				}
+				if r.Config.Cluster.Threshold == 0 {
+					t.Error("cluster threshold not defaulted")
+				}
+			},
+		},
+		{
+			name:   "file",
+			config: testConfig,
+			check: func(t *testing.T, r Resolved) {
+				if r.Path != "/nned.yaml" {
+					t.Errorf("Path = %q", r.Path)
+				}
+				if r.Config.RefreshInterval != 60 || r.Sources["interval"] != SourceFile {
+					t.Errorf("interval = %d from %s", r.Config.RefreshInterval, r.Sources["interval"])
+				}
+				if r.Config.MaxBackoff != 600 || r.Sources["max-backoff"] != SourceFile {
+					t.Errorf("max-backoff = %d from %s", r.Config.MaxBackoff, r.Sources["max-backoff"])
+				}
+				if !r.Config.LastDate.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("last-date = %v", r.Config.LastDate)
+				}
+				if len(r.Config.NewsFeeds) != 1 || r.Config.NewsFeeds[0].Title != "Go" || r.Sources["feeds"] != SourceFile {
+					t.Errorf("feeds = %+v from %s", r.Config.NewsFeeds, r.Sources["feeds"])
+				}
+			},
+		},
+		{
+			name:   "env over file",
+			config: testConfig,
+			env: map[string]string{
+				"NNED_INTERVAL":  "120",
+				"NNED_FEEDS":     "https://a.example/feed, ,https://b.example/feed",
+				"NNED_LAST_DATE": "2024-02-01",
+				"NNED_DEBUG":     "true",
+			},
+			check: func(t *testing.T, r Resolved) {
+				if r.Config.RefreshInterval != 120 || r.Sources["interval"] != SourceEnv {
+					t.Errorf("interval = %d from %s", r.Config.RefreshInterval, r.Sources["interval"])
+				}
+				if len(r.Config.NewsFeeds) != 2 || r.Config.NewsFeeds[1].Url != "https://b.example/feed" {
+					t.Errorf("feeds = %+v", r.Config.NewsFeeds)
+				}
+				if r.Config.LastDate.Format(time.DateOnly) != "2024-02-01" || r.Sources["last-date"] != SourceEnv {
+					t.Errorf("last-date = %v from %s", r.Config.LastDate, r.Sources["last-date"])
+				}
+				if !r.Config.Debug || r.Sources["debug"] != SourceEnv {
+					t.Errorf("debug = %v from %s", r.Config.Debug, r.Sources["debug"])
+				}
+			},
+		},
+		{
+			name:    "flags over env",
+			config:  testConfig,
+			env:     map[string]string{"NNED_INTERVAL": "120", "NNED_FEEDS": "https://a.example/feed"},
+			options: Options{RefreshInterval: 15, Feeds: "https://c.example/feed", LastDate: flagDate},
+			check: func(t *testing.T, r Resolved) {
+				if r.Config.RefreshInterval != 15 || r.Sources["interval"] != SourceFlag {
+					t.Errorf("interval = %d from %s", r.Config.RefreshInterval, r.Sources["interval"])
+				}
+				if len(r.Config.NewsFeeds) != 1 || r.Config.NewsFeeds[0].Url != "https://c.example/feed" || r.Sources["feeds"] != SourceFlag {
+					t.Errorf("feeds = %+v from %s", r.Config.NewsFeeds, r.Sources["feeds"])
+				}
+				if !r.Config.LastDate.Equal(flagDate) || r.Sources["last-date"] != SourceFlag {
+					t.Errorf("last-date = %v from %s", r.Config.LastDate, r.Sources["last-date"])
+				}
+			},
+		},
+		{
+			name:   "empty env is unset",
+			config: testConfig,
+			env:    map[string]string{"NNED_INTERVAL": ""},
+			check: func(t *testing.T, r Resolved) {
+				if r.Config.RefreshInterval != 60 {
+					t.Errorf("interval = %d, want the file's", r.Config.RefreshInterval)
+				}
+			},
+		},
+		{
+			name:   "sync key from env",
+			config: "sync: {username: alice}",
+			env:    map[string]string{"NNED_SYNC_API_KEY": "secret"},
+			check: func(t *testing.T, r Resolved) {
+				if r.Config.Sync.APIKey != "secret" || r.Sources["sync"] != SourceEnv {
+					t.Errorf("sync = %+v from %s", r.Config.Sync, r.Sources["sync"])
+				}
+			},
+		},
+		{
+			name:   "sync from env",
+			config: "interval: 60",
+			env:    map[string]string{"NNED_SYNC_USERNAME": "bob", "NNED_SYNC_API_KEY": "secret"},
+			check: func(t *testing.T, r Resolved) {
+				if r.Config.Sync.Username != "bob" || r.Config.Sync.APIKey != "secret" || r.Sources["sync"] != SourceEnv {
+					t.Errorf("sync = %+v from %s", r.Config.Sync, r.Sources["sync"])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ResolveConfig(deps(t, tt.config, tt.env), "/nned.yaml", tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, r)
+		})
+	}
+}
+
+func TestResolveConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     map[string]string
+		wantErr string
+	}{
+		{"invalid yaml", "feeds: [", nil, "invalid config"},
+		{"invalid interval", "", map[string]string{"NNED_INTERVAL": "soon"}, "NNED_INTERVAL"},
+		{"negative interval", "", map[string]string{"NNED_INTERVAL": "-1"}, "NNED_INTERVAL"},
+		{"invalid date", "", map[string]string{"NNED_LAST_DATE": "yesterday"}, "NNED_LAST_DATE"},
+		{"invalid max-backoff", "", map[string]string{"NNED_MAX_BACKOFF": "0"}, "NNED_MAX_BACKOFF"},
+		{"invalid bool", "", map[string]string{"NNED_DEBUG": "maybe"}, "NNED_DEBUG"},
+		{"invalid threshold", "cluster: {threshold: 2}", nil, "threshold"},
+		{"invalid rule", "rules: [{name: r, actions: [hide]}]", nil, "rule r"},
+		{"invalid notify", "notify: {method: pigeon}", nil, "notify"},
+		{"sync without key", "sync: {username: alice}", nil, "sync"},
+		{"invalid base url", "sync: {base-url: nned.example}", nil, "base-url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			if config == "" {
+				config = "interval: 60"
+			}
+			_, err := ResolveConfig(deps(t, config, tt.env), "/nned.yaml", Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveConfigMissingFile(t *testing.T) {
+	_, err := ResolveConfig(deps(t, "", nil), "/missing.yaml", Options{})
+	if err == nil {
+		t.Error("an explicit config path that does not exist was accepted")
+	}
+}
+
+func TestResolveConfigDefaultLocation(t *testing.T) {
+	d := deps(t, "", nil)
+	r, err := ResolveConfig(d, "", Options{})
+	if err != nil || r.Path != "" {
+		t.Fatalf("without a config file: %q, %v", r.Path, err)
+	}
+
+	path := filepath.Join(xdg.ConfigHome, ".nned.yaml")
+	afero.WriteFile(d.Fs, path, []byte("feeds: ["), 0o644)
+	if _, err := ResolveConfig(d, "", Options{}); err == nil {
+		t.Error("a broken config file in a default location was ignored")
+	}
+	afero.WriteFile(d.Fs, path, []byte("interval: 45"), 0o644)
+	r, err = ResolveConfig(d, "", Options{})
+	if err != nil || r.Path != path || r.Config.RefreshInterval != 45 {
+		t.Errorf("found %q with interval %d, %v", r.Path, r.Config.RefreshInterval, err)
+	}
+}
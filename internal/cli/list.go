@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/store"
+)
+
+var ListFormats = []string{"plain", "json", "csv", "tsv"}
+
+type listArticle struct {
+	ID          string     `json:"id"`
+	Story       string     `json:"story,omitempty"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Date        *time.Time `json:"date"`
+	Updated     *time.Time `json:"updated,omitempty"`
+	Feed        string     `json:"feed"`
+	Source      string     `json:"source"`
+	Description string     `json:"description,omitempty"`
+}
+
+// FilterFeeds keeps the feeds whose title or URL matches one of names. An
+// empty list keeps every feed.
+func FilterFeeds(feeds []c.Feed, names []string) ([]c.Feed, error) {
+	if len(names) == 0 {
+		return feeds, nil
+	}
+	filtered := make([]c.Feed, 0)
+	for _, feed := range feeds {
+		for _, name := range names {
+			if strings.EqualFold(feed.Title, name) || feed.Url == name {
+				filtered = append(filtered, feed)
+				break
+			}
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no feed matches %s", strings.Join(names, ", "))
+	}
+	return filtered, nil
+}
+
+// FilterCategory keeps the feeds of a category. An empty category keeps
+// every feed.
+func FilterCategory(feeds []c.Feed, category string) ([]c.Feed, error) {
+	if category == "" {
+		return feeds, nil
+	}
+	filtered := make([]c.Feed, 0)
+	for _, feed := range feeds {
+		if strings.EqualFold(feed.Category, category) {
+			filtered = append(filtered, feed)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no feed in category %s", category)
+	}
+	return filtered, nil
+}
+
+// WriteArticles prints the articles newest first. The JSON output carries
+// the story of each article when story is set.
+func WriteArticles(w io.Writer, format string, articles []c.Article, story func(key string) string) error {
+	sorted := make([]*c.Article, 0, len(articles))
+	for i := range articles {
+		sorted = append(sorted, &articles[i])
+	}
+	sort.Sort(c.ByDate(sorted))
+
+	switch format {
+	case "plain":
+		for _, a := range sorted {
+			fmt.Fprintf(w, "%s  [%s] %s\n  %s\n", a.Date.Format("2006-01-02 15:04"), feedName(a), a.Title, a.Link)
+		}
+		return nil
+	case "json":
+		out := make([]listArticle, 0, len(sorted))
+		for _, a := range sorted {
+			key := store.Key(*a)
+			entry := listArticle{
+				ID:          key,
+				Title:       a.Title,
+				Link:        a.Link,
+				Date:        a.Date,
+				Updated:     a.Updated,
+				Feed:        feedName(a),
+				Source:      a.Source,
+				Description: a.Description,
+			}
+			if story != nil {
+				entry.Story = story(key)
+			}
+			out = append(out, entry)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write([]string{"date", "feed", "title", "link"})
+		for _, a := range sorted {
+			cw.Write([]string{a.Date.Format(time.RFC3339), feedName(a), a.Title, a.Link})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(ListFormats, ", "))
+}
+
+func feedName(a *c.Article) string {
+	if a.SourceTitle != "" {
+		return a.SourceTitle
+	}
+	return a.Source
+}
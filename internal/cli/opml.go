@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	c "nned/internal/common"
+	"nned/internal/opml"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportOPML merges the feeds of an OPML document into the feeds list of
+// the config file, editing the YAML in place so that other keys and
+// comments survive. Feeds already present are skipped by URL.
+func ImportOPML(d c.Dependencies, configPathOption string, r io.Reader) (string, int, int, error) {
+	doc, err := opml.Parse(r)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	path, err := getConfigPath(d.Fs, configPathOption)
+	if err != nil && !isConfigNotFound(err) {
+		return "", 0, 0, err
+	}
+	if err != nil {
+		home, _ := homedir.Dir()
+		path = filepath.Join(home, ".nned.yaml")
+	}
+
+	root, err := readConfigNode(d.Fs, path)
+	if err != nil {
+		return path, 0, 0, err
+	}
+	feeds := mappingValue(root, "feeds", yaml.SequenceNode)
+
+	known := make(map[string]bool)
+	for _, feed := range feeds.Content {
+		var f c.Feed
+		if feed.Decode(&f) == nil {
+			known[f.Url] = true
+		}
+	}
+
+	added, skipped := 0, 0
+	for _, entry := range doc.Entries() {
+		if known[entry.Url] {
+			skipped++
+			continue
+		}
+		known[entry.Url] = true
+		var node yaml.Node
+		err = node.Encode(c.Feed{
+			Url:      entry.Url,
+			Title:    entry.Title,
+			Category: entry.Category,
+		})
+		if err != nil {
+			return path, added, skipped, err
+		}
+		feeds.Content = append(feeds.Content, &node)
+		added++
+	}
+
+	if added == 0 {
+		return path, added, skipped, nil
+	}
+	return path, added, skipped, writeConfigNode(d.Fs, path, root)
+}
+
+func ExportOPML(w io.Writer, config c.Config) error {
+	entries := make([]opml.Entry, 0, len(config.NewsFeeds))
+	for _, feed := range config.NewsFeeds {
+		entries = append(entries, opml.Entry{
+			Title:    feed.Title,
+			Url:      feed.Url,
+			Category: feed.Category,
+		})
+	}
+	return opml.New("nned feeds", entries).Write(w)
+}
+
+func readConfigNode(fs afero.Fs, path string) (*yaml.Node, error) {
+	var doc yaml.Node
+	content, err := afero.ReadFile(fs, path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if len(bytes.TrimSpace(content)) > 0 {
+		err = yaml.Unmarshal(content, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("invalid config: expected a mapping")
+	}
+	return &doc, nil
+}
+
+// mappingValue returns the value node of key in the document's top level
+// mapping, adding an empty node of the given kind when the key is missing.
+func mappingValue(doc *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value := mapping.Content[i+1]
+			if value.Kind != kind {
+				value.Kind = kind
+				value.Tag = ""
+				value.Value = ""
+				value.Style = 0
+			}
+			return value
+		}
+	}
+	value := &yaml.Node{Kind: kind}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
+
+func writeConfigNode(fs afero.Fs, path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("unable to write config: %w", err)
+	}
+	err = fs.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("unable to write config: %w", err)
+	}
+	err = afero.WriteFile(fs, path, buf.Bytes(), 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to write config: %w", err)
+	}
+	return nil
+}
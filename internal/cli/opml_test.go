@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	c "nned/internal/common"
+
+	"github.com/spf13/afero"
+)
+
+const subscriptions = `<?xml version="1.0"?>
+<opml version="2.0">
+  <body>
+    <outline text="Go" xmlUrl="https://go.dev/blog/feed.atom"/>
+    <outline text="Tech">
+      <outline text="Rust" xmlUrl="https://blog.rust-lang.org/feed.xml"/>
+      <outline text="Rust again" xmlUrl="https://blog.rust-lang.org/feed.xml"/>
+    </outline>
+    <outline text="Zig" xmlUrl="https://ziglang.org/news/index.xml"/>
+  </body>
+</opml>`
+
+func TestImportOPML(t *testing.T) {
+	config := `# refresh every minute
+interval: 60
+feeds:
+  # the Go blog
+  - url: https://go.dev/blog/feed.atom
+    title: Go
+`
+	d := deps(t, config, nil)
+	path, added, skipped, err := ImportOPML(d, "/nned.yaml", strings.NewReader(subscriptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/nned.yaml" || added != 2 || skipped != 2 {
+		t.Errorf("ImportOPML() = %q, %d added, %d skipped; want /nned.yaml, 2, 2", path, added, skipped)
+	}
+
+	content, err := afero.ReadFile(d.Fs, "/nned.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comment := range []string{"# refresh every minute", "# the Go blog"} {
+		if !strings.Contains(string(content), comment) {
+			t.Errorf("comment %q lost:\n%s", comment, content)
+		}
+	}
+	r, err := ResolveConfig(d, "/nned.yaml", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []c.Feed{
+		{Url: "https://go.dev/blog/feed.atom", Title: "Go"},
+		{Url: "https://blog.rust-lang.org/feed.xml", Title: "Rust", Category: "Tech"},
+		{Url: "https://ziglang.org/news/index.xml", Title: "Zig"},
+	}
+	if !reflect.DeepEqual(r.Config.NewsFeeds, want) {
+		t.Errorf("feeds = %+v, want %+v", r.Config.NewsFeeds, want)
+	}
+	if r.Config.RefreshInterval != 60 {
+		t.Errorf("interval = %d, want the file's 60", r.Config.RefreshInterval)
+	}
+
+	_, added, skipped, err = ImportOPML(d, "/nned.yaml", strings.NewReader(subscriptions))
+	if err != nil || added != 0 || skipped != 4 {
+		t.Errorf("second import: %d added, %d skipped, %v; want 0, 4", added, skipped, err)
+	}
+	again, _ := afero.ReadFile(d.Fs, "/nned.yaml")
+	if string(again) != string(content) {
+		t.Errorf("config rewritten without new feeds:\n%s", again)
+	}
+}
+
+func TestImportOPMLNewConfig(t *testing.T) {
+	d := deps(t, "", nil)
+	_, added, _, err := ImportOPML(d, "/config/nned.yaml", strings.NewReader(subscriptions))
+	if err != nil || added != 3 {
+		t.Fatalf("ImportOPML() = %d added, %v", added, err)
+	}
+	r, err := ResolveConfig(d, "/config/nned.yaml", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Config.NewsFeeds) != 3 {
+		t.Errorf("feeds = %+v, want the 3 imported", r.Config.NewsFeeds)
+	}
+}
+
+func TestImportOPMLInvalid(t *testing.T) {
+	d := deps(t, "feeds: [", nil)
+	if _, _, _, err := ImportOPML(d, "/nned.yaml", strings.NewReader(subscriptions)); err == nil {
+		t.Error("import into an invalid config succeeded")
+	}
+	if _, _, _, err := ImportOPML(d, "/nned.yaml", strings.NewReader("not xml")); err == nil {
+		t.Error("import of an invalid OPML file succeeded")
+	}
+	content, _ := afero.ReadFile(d.Fs, "/nned.yaml")
+	if string(content) != "feeds: [" {
+		t.Errorf("invalid config was changed to %q", content)
+	}
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	c "nned/internal/common"
+	"nned/internal/rules"
+	"nned/internal/store"
+)
+
+// TestRule runs the named rule against every stored article without
+// changing anything, and prints the articles it matches.
+func TestRule(w io.Writer, st *store.Store, config c.Config, name string) error {
+	engine, err := rules.Compile(config.Rules)
+	if err != nil {
+		return err
+	}
+	rule, ok := engine.Rule(name)
+	if !ok {
+		names := make([]string, 0, len(config.Rules))
+		for _, r := range config.Rules {
+			names = append(names, r.Name)
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("unknown rule %q, the config has no rules", name)
+		}
+		return fmt.Errorf("unknown rule %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	records := st.Records()
+	matched := make([]c.Article, 0)
+	for _, r := range records {
+		if result := rule.Apply(r.Article); len(result.Matched) > 0 {
+			matched = append(matched, result.Article)
+		}
+	}
+	err = WriteArticles(w, "plain", matched, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d of %d stored articles match rule %s (%s)\n", len(matched), len(records), name, strings.Join(rule.Actions(), ", "))
+	return nil
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const reloadDelay = 200 * time.Millisecond
+
+// WatchConfig resolves the config again whenever the file at path changes and
+// passes the result to onChange. The directories are watched rather than the
+// file, since most editors save by replacing it. A symlinked config is
+// followed, and the directory of its target is watched too; the target is
+// looked up again on every change, as dotfile managers relink it. Changes are
+// debounced, and a config that fails to resolve is reported to onError and
+// otherwise ignored.
+func WatchConfig(d c.Dependencies, path string, options Options, onChange func(c.Config), onError func(error)) (func() error, error) {
+	if path == "" {
+		return func() error { return nil }, nil
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	target := path
+	follow := func() error {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			// Between the removal and the creation of the file during a
+			// save; the previous target stays watched.
+			return nil
+		}
+		target = resolved
+		err = watcher.Add(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		return watcher.Add(filepath.Dir(target))
+	}
+	err = follow()
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	reload := func() {
+		reloadConfig(d, path, options, onChange, onError)
+	}
+
+	go func() {
+		var pending *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Clean(event.Name)
+				if name != path && name != target {
+					continue
+				}
+				if !event.Has(fsnotify.Write | fsnotify.Create | fsnotify.Rename | fsnotify.Remove) {
+					continue
+				}
+				err := follow()
+				if err != nil {
+					onError(err)
+				}
+				if pending != nil {
+					pending.Stop()
+				}
+				pending = time.AfterFunc(reloadDelay, reload)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onError(err)
+			}
+		}
+	}()
+
+	return watcher.Close, nil
+}
+
+// reloadConfig resolves the config at path and passes it to onChange, or
+// reports why it was not reloaded to onError.
+func reloadConfig(d c.Dependencies, path string, options Options, onChange func(c.Config), onError func(error)) {
+	resolved, err := ResolveConfig(d, path, options)
+	if err == nil && len(resolved.Config.NewsFeeds) == 0 {
+		err = errors.New("no news feeds")
+	}
+	if err != nil {
+		onError(fmt.Errorf("config not reloaded: %w", err))
+		return
+	}
+	onChange(resolved.Config)
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/spf13/afero"
+)
+
+func TestReloadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		options Options
+		feeds   int
+		wantErr string
+	}{
+		{"feeds added", testConfig + "  - url: https://blog.rust-lang.org/feed.xml\n", Options{}, 2, ""},
+		{"feeds from flags", testConfig, Options{Feeds: "https://a.example/feed,https://b.example/feed,https://c.example/feed"}, 3, ""},
+		{"invalid yaml", "feeds: [", Options{}, 0, "config not reloaded"},
+		{"no feeds", "interval: 60", Options{}, 0, "no news feeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *c.Config
+			var gotErr error
+			reloadConfig(deps(t, tt.config, nil), "/nned.yaml", tt.options,
+				func(config c.Config) { got = &config },
+				func(err error) { gotErr = err })
+			if tt.wantErr != "" {
+				if got != nil || gotErr == nil || !strings.Contains(gotErr.Error(), tt.wantErr) {
+					t.Fatalf("reloaded %v with error %v, want an error containing %q", got, gotErr, tt.wantErr)
+				}
+				return
+			}
+			if gotErr != nil || got == nil {
+				t.Fatalf("not reloaded: %v", gotErr)
+			}
+			if len(got.NewsFeeds) != tt.feeds {
+				t.Errorf("feeds = %+v, want %d", got.NewsFeeds, tt.feeds)
+			}
+		})
+	}
+}
+
+// TestWatchConfig needs the real file system, which fsnotify watches.
+func TestWatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nned.yaml")
+	write := func(content string) {
+		t.Helper()
+		// Saved the way most editors do, by replacing the file.
+		tmp := filepath.Join(dir, ".nned.yaml.swp")
+		if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(testConfig)
+
+	changes := make(chan c.Config, 4)
+	errs := make(chan error, 4)
+	d := c.Dependencies{Fs: afero.NewOsFs(), LookupEnv: func(string) (string, bool) { return "", false }}
+	stop, err := WatchConfig(d, path, Options{},
+		func(config c.Config) { changes <- config },
+		func(err error) { errs <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	write(testConfig + "  - url: https://blog.rust-lang.org/feed.xml\n")
+	select {
+	case config := <-changes:
+		if len(config.NewsFeeds) != 2 {
+			t.Errorf("feeds = %+v, want the added one too", config.NewsFeeds)
+		}
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not picked up")
+	}
+
+	write("feeds: [")
+	select {
+	case config := <-changes:
+		t.Errorf("invalid config reloaded: %+v", config)
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "config not reloaded") {
+			t.Errorf("error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid config not reported")
+	}
+}
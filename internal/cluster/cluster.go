@@ -0,0 +1,292 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"nned/internal/canonical"
+	c "nned/internal/common"
+)
+
+const (
+	DefaultThreshold = 0.5
+
+	numHashes = 64
+	bands     = 16
+	rows      = numHashes / bands
+)
+
+type signature [numHashes]uint64
+
+// Index clusters articles that cover the same story in different feeds as
+// they arrive. Articles are in the same story when their canonical links
+// match or when the estimated Jaccard similarity of their title shingles is
+// at least the threshold. Candidates are found with MinHash and banded
+// locality sensitive hashing, so an article is only compared with the few
+// that share a band with it. An Index is safe for concurrent use.
+type Index struct {
+	mu        sync.Mutex
+	threshold float64
+	next      int
+	parent    map[string]string
+	order     map[string]int
+	dates     map[string]time.Time
+	feeds     map[string]string
+	links     map[string]string
+	sigs      map[string]*signature
+	buckets   map[uint64][]string
+}
+
+func NewIndex(threshold float64) *Index {
+	return &Index{
+		threshold: threshold,
+		parent:    make(map[string]string),
+		order:     make(map[string]int),
+		dates:     make(map[string]time.Time),
+		feeds:     make(map[string]string),
+		links:     make(map[string]string),
+		sigs:      make(map[string]*signature),
+		buckets:   make(map[uint64][]string),
+	}
+}
+
+// Add puts the article stored under key into its story. Adding a key again
+// does nothing.
+func (x *Index) Add(key string, a c.Article) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.parent[key]; ok {
+		return
+	}
+	x.parent[key] = key
+	x.order[key] = x.next
+	x.next++
+	x.feeds[key] = feedOf(a)
+	if a.Date != nil {
+		x.dates[key] = *a.Date
+	}
+
+	if a.Link != "" {
+		link := canonical.Link(a.Link)
+		if other, ok := x.links[link]; ok {
+			x.union(other, key)
+		} else {
+			x.links[link] = key
+		}
+	}
+
+	sig := minHash(a.Title)
+	if sig == nil {
+		return
+	}
+	x.sigs[key] = sig
+	for band := 0; band < bands; band++ {
+		bucket := bandKey(band, sig)
+		for _, other := range x.buckets[bucket] {
+			if x.find(other) == x.find(key) || x.feeds[other] == x.feeds[key] {
+				continue
+			}
+			if similarity(sig, x.sigs[other]) >= x.threshold {
+				x.union(other, key)
+			}
+		}
+		x.buckets[bucket] = append(x.buckets[bucket], key)
+	}
+}
+
+// Story returns the key of the first article added to the story of key, or
+// key itself when it was never added.
+func (x *Index) Story(key string) string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.parent[key]; !ok {
+		return key
+	}
+	return x.find(key)
+}
+
+// Evict forgets the articles dated before date, which are no longer shown,
+// so that the index does not keep growing. A story that loses its first
+// article is led by the earliest one left.
+func (x *Index) Evict(date time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	evicted := make(map[string]bool)
+	for key, d := range x.dates {
+		if d.Before(date) {
+			evicted[key] = true
+		}
+	}
+	if len(evicted) == 0 {
+		return
+	}
+
+	roots := make(map[string]string, len(x.parent))
+	for key := range x.parent {
+		roots[key] = x.find(key)
+	}
+	leaders := make(map[string]string)
+	for key, root := range roots {
+		if evicted[key] {
+			continue
+		}
+		if leader, ok := leaders[root]; !ok || x.order[key] < x.order[leader] {
+			leaders[root] = key
+		}
+	}
+	for key, root := range roots {
+		if !evicted[key] {
+			x.parent[key] = leaders[root]
+			continue
+		}
+		delete(x.parent, key)
+		delete(x.order, key)
+		delete(x.dates, key)
+		delete(x.feeds, key)
+		delete(x.sigs, key)
+	}
+	for link, key := range x.links {
+		if evicted[key] {
+			delete(x.links, link)
+		}
+	}
+	for bucket, keys := range x.buckets {
+		keys = slices.DeleteFunc(keys, func(key string) bool { return evicted[key] })
+		if len(keys) == 0 {
+			delete(x.buckets, bucket)
+		} else {
+			x.buckets[bucket] = keys
+		}
+	}
+}
+
+func (x *Index) find(key string) string {
+	for x.parent[key] != key {
+		x.parent[key] = x.parent[x.parent[key]]
+		key = x.parent[key]
+	}
+	return key
+}
+
+// union merges two stories under the root of the article added first, so
+// that a story keeps its key while sources join it.
+func (x *Index) union(a, b string) {
+	ra, rb := x.find(a), x.find(b)
+	if ra == rb {
+		return
+	}
+	if x.order[rb] < x.order[ra] {
+		ra, rb = rb, ra
+	}
+	x.parent[rb] = ra
+}
+
+// feedOf identifies the feed of an article; articles of the same feed are
+// never clustered.
+func feedOf(a c.Article) string {
+	if a.FeedUrl != "" {
+		return a.FeedUrl
+	}
+	return "source:" + a.Source
+}
+
+// minHash returns the signature of the shingles of a title, or nil when the
+// title has no words.
+func minHash(title string) *signature {
+	shingles := shingle(normalize(title))
+	if len(shingles) == 0 {
+		return nil
+	}
+	var sig signature
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, s := range shingles {
+		for i := range sig {
+			if h := mix(s ^ seeds[i]); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return &sig
+}
+
+func similarity(a, b *signature) float64 {
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / numHashes
+}
+
+func bandKey(band int, sig *signature) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(band))
+	h.Write(buf)
+	for _, v := range sig[band*rows : (band+1)*rows] {
+		binary.LittleEndian.PutUint64(buf, v)
+		h.Write(buf)
+	}
+	return h.Sum64()
+}
+
+// normalize lowercases a title and reduces it to words separated by single
+// spaces, so that punctuation and spacing do not affect the shingles.
+func normalize(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// shingle hashes the words of a text and its pairs of adjacent words. Words
+// rather than characters keep short titles that differ in a single word or
+// number apart.
+func shingle(text string) []uint64 {
+	words := strings.Fields(text)
+	seen := make(map[uint64]bool)
+	shingles := make([]uint64, 0, 2*len(words))
+	add := func(s string) {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		if v := h.Sum64(); !seen[v] {
+			seen[v] = true
+			shingles = append(shingles, v)
+		}
+	}
+	for i, word := range words {
+		add(word)
+		if i > 0 {
+			add(words[i-1] + " " + word)
+		}
+	}
+	return shingles
+}
+
+var seeds = func() [numHashes]uint64 {
+	var seeds [numHashes]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		x = mix(x + uint64(i))
+		seeds[i] = x
+	}
+	return seeds
+}()
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+)
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b c.Article
+		same bool
+	}{
+		{
+			"same link",
+			c.Article{Title: "Release notes", Link: "https://go.dev/blog/go1.24", FeedUrl: "a"},
+			c.Article{Title: "Go is out", Link: "https://go.dev/blog/go1.24?utm_source=hn", FeedUrl: "b"},
+			true,
+		},
+		{
+			"similar titles",
+			c.Article{Title: "Go 1.24 is released with generic type aliases", FeedUrl: "a"},
+			c.Article{Title: "Go 1.24 released with generic type aliases!", FeedUrl: "b"},
+			true,
+		},
+		{
+			"similar titles in one feed",
+			c.Article{Title: "Go 1.24 is released with generic type aliases", FeedUrl: "a"},
+			c.Article{Title: "Go 1.24 released with generic type aliases!", FeedUrl: "a"},
+			false,
+		},
+		{
+			"different titles",
+			c.Article{Title: "Go 1.24 is released", FeedUrl: "a"},
+			c.Article{Title: "Rust 1.85 is released", FeedUrl: "b"},
+			false,
+		},
+		{
+			"numbers differ",
+			c.Article{Title: "Weekly roundup 41", FeedUrl: "a"},
+			c.Article{Title: "Weekly roundup 42", FeedUrl: "b"},
+			false,
+		},
+		{
+			"no title",
+			c.Article{FeedUrl: "a"},
+			c.Article{FeedUrl: "b"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := NewIndex(DefaultThreshold)
+			x.Add("a", tt.a)
+			x.Add("b", tt.b)
+			if x.Story("a") != "a" {
+				t.Errorf("Story(a) = %q, want the first article", x.Story("a"))
+			}
+			if got := x.Story("b") == x.Story("a"); got != tt.same {
+				t.Errorf("same story = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestIndexKeepsFirstKey(t *testing.T) {
+	x := NewIndex(DefaultThreshold)
+	x.Add("first", c.Article{Title: "unrelated", Link: "https://example.com/a", FeedUrl: "1"})
+	x.Add("second", c.Article{Title: "Big news about something", FeedUrl: "2"})
+	x.Add("third", c.Article{Title: "Big news about something", Link: "https://example.com/a", FeedUrl: "3"})
+	for _, key := range []string{"first", "second", "third"} {
+		if got := x.Story(key); got != "first" {
+			t.Errorf("Story(%q) = %q, want first", key, got)
+		}
+	}
+	x.Add("second", c.Article{Title: "something else", FeedUrl: "2"})
+	if got := x.Story("second"); got != "first" {
+		t.Errorf("adding a key again moved it to %q", got)
+	}
+	if got := x.Story("unknown"); got != "unknown" {
+		t.Errorf("Story(unknown) = %q", got)
+	}
+}
+
+func TestIndexEvict(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC)
+		return &t
+	}
+	x := NewIndex(DefaultThreshold)
+	x.Add("old", c.Article{Title: "Go 1.24 is released with generic type aliases", Link: "https://go.dev/1", FeedUrl: "a", Date: day(1)})
+	x.Add("new", c.Article{Title: "Go 1.24 released with generic type aliases", FeedUrl: "b", Date: day(3)})
+	x.Add("newer", c.Article{Title: "Go 1.24 is out with generic type aliases", Link: "https://go.dev/1", FeedUrl: "c", Date: day(4)})
+	x.Add("undated", c.Article{Title: "Rust 1.85", FeedUrl: "a"})
+	if got := x.Story("newer"); got != "old" {
+		t.Fatalf("Story(newer) = %q before evicting, want old", got)
+	}
+
+	x.Evict(*day(2))
+	if got := x.Story("new"); got != "new" {
+		t.Errorf("Story(new) = %q, want new to lead the story now", got)
+	}
+	if got := x.Story("newer"); got != "new" {
+		t.Errorf("Story(newer) = %q, want new", got)
+	}
+	if got := x.Story("undated"); got != "undated" {
+		t.Errorf("Story(undated) = %q", got)
+	}
+	if _, ok := x.parent["old"]; ok || len(x.order) != 3 || len(x.dates) != 2 || len(x.feeds) != 3 || len(x.sigs) != 3 || len(x.links) != 0 {
+		t.Errorf("old article left in the index: %d orders, %d dates, %d feeds, %d sigs, %d links",
+			len(x.order), len(x.dates), len(x.feeds), len(x.sigs), len(x.links))
+	}
+	for _, keys := range x.buckets {
+		if slices.Contains(keys, "old") {
+			t.Fatal("old article left in a bucket")
+		}
+	}
+
+	x.Add("later", c.Article{Title: "Go 1.24 released with generic type aliases", FeedUrl: "d", Date: day(5)})
+	if got := x.Story("later"); got != "new" {
+		t.Errorf("Story(later) = %q, want new", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Go 1.24: Released!": "go 1 24 released",
+		"  spaced   out  ":   "spaced out",
+		"Ünïcode — letters":  "ünïcode letters",
+		"":                   "",
+	}
+	for in, want := range tests {
+		if got := normalize(in); got != want {
+			t.Errorf("normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
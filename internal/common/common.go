@@ -1,6 +1,7 @@
 package common
 
 import (
+	"fmt"
 	"log"
 	"time"
 
@@ -17,26 +18,89 @@ type Config struct {
 	NewsFeeds       []Feed    `yaml:"feeds"`
 	Debug           bool      `yaml:"debug"`
 	LastDate        time.Time `yaml:"last-date"`
+	MaxBackoff      int       `yaml:"max-backoff"`
+	Browser         string    `yaml:"browser"`
+	Cluster         Cluster   `yaml:"cluster"`
+	Rules           []Rule    `yaml:"rules,omitempty"`
+	Notify          Notify    `yaml:"notify"`
+	Sync            Sync      `yaml:"sync"`
+}
+
+// Sync holds the credentials mobile clients use to sync with the server
+// over the Fever API, and the address other devices reach the server at,
+// which links in the served feeds are built from.
+type Sync struct {
+	Username string `yaml:"username,omitempty"`
+	APIKey   string `yaml:"api-key,omitempty"`
+	BaseURL  string `yaml:"base-url,omitempty"`
+}
+
+// Notify configures notifications for new articles. Batch and MinInterval
+// are in seconds.
+type Notify struct {
+	Enabled     bool   `yaml:"enabled"`
+	Method      string `yaml:"method,omitempty"`
+	Command     string `yaml:"command,omitempty"`
+	Filter      string `yaml:"filter,omitempty"`
+	Batch       int    `yaml:"batch,omitempty"`
+	MinInterval int    `yaml:"min-interval,omitempty"`
+}
+
+type Cluster struct {
+	Enabled   bool    `yaml:"enabled"`
+	Threshold float64 `yaml:"threshold,omitempty"`
 }
 
 type Dependencies struct {
-	Fs afero.Fs
+	Fs        afero.Fs
+	LookupEnv func(key string) (string, bool)
 }
 
 type Feed struct {
-	Url   string `yaml:"url"`
-	Title string `yaml:"title"`
-	Color string `yaml:"color"`
+	Url      string   `yaml:"url"`
+	Title    string   `yaml:"title"`
+	Color    string   `yaml:"color,omitempty"`
+	Category string   `yaml:"category,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+	Interval int      `yaml:"interval,omitempty"`
+	FullText bool     `yaml:"fulltext,omitempty"`
+	Selector string   `yaml:"selector,omitempty"`
+}
+
+// Rule matches articles on every condition that is set and applies its
+// actions to them. Title, description and author are regular expressions;
+// the ages are durations such as 48h.
+type Rule struct {
+	Name        string   `yaml:"name"`
+	Feed        string   `yaml:"feed,omitempty"`
+	Category    string   `yaml:"category,omitempty"`
+	Title       string   `yaml:"title,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Author      string   `yaml:"author,omitempty"`
+	OlderThan   string   `yaml:"older-than,omitempty"`
+	NewerThan   string   `yaml:"newer-than,omitempty"`
+	Actions     []string `yaml:"actions"`
+	Color       string   `yaml:"color,omitempty"`
+	Tag         string   `yaml:"tag,omitempty"`
 }
 
 type Article struct {
+	ID          string
 	Title       string
 	Description string
+	Content     string
 	Link        string
+	Author      string
 	Date        *time.Time
+	Updated     *time.Time
 	Source      string
+	FeedUrl     string
 	SourceTitle string
 	SourceColor string
+	Category    string
+	Tags        []string
+	Highlight   string
+	Starred     bool
 }
 
 type MessageUpdate[T any] struct {
@@ -46,6 +110,38 @@ type MessageUpdate[T any] struct {
 	VersionVector int
 }
 
+type FeedError struct {
+	Url  string
+	Time time.Time
+	Err  error
+}
+
+func (e FeedError) Error() string {
+	if e.Url == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Url, e.Err)
+}
+
+func (e FeedError) Unwrap() error { return e.Err }
+
+// DateFormats are the layouts dates are given in on the command line, in
+// the config and in searches, in local time.
+var DateFormats = []string{"2006-01-02", "2006-01-02 15:04:05"}
+
+// ParseDate parses s in the first of DateFormats that fits.
+func ParseDate(s string) (time.Time, error) {
+	var err error
+	for _, format := range DateFormats {
+		var t time.Time
+		t, err = time.ParseInLocation(format, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
 type ByDate []*Article
 
 func (a ByDate) Len() int           { return len(a) }
@@ -0,0 +1,248 @@
+package digest
+
+import (
+	"cmp"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/planet"
+	"nned/internal/store"
+	"nned/internal/ui/util"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/afero"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatText     = "txt"
+
+	GroupByFeed     = "feed"
+	GroupByCategory = "category"
+
+	summaryLength = 280
+)
+
+var (
+	Formats = []string{FormatMarkdown, FormatHTML, FormatText}
+	GroupBy = []string{GroupByFeed, GroupByCategory}
+
+	//go:embed templates
+	defaults embed.FS
+)
+
+// Digest is what templates are executed with.
+type Digest struct {
+	Title   string
+	Since   time.Time
+	Until   time.Time
+	GroupBy string
+	Total   int
+	Groups  []Group
+}
+
+type Group struct {
+	Name     string
+	Articles []Article
+}
+
+type Article struct {
+	Title    string
+	Link     string
+	Feed     string
+	Category string
+	Tags     []string
+	Author   string
+	Date     time.Time
+	Summary  string
+	Starred  bool
+	Also     []string
+
+	key string
+}
+
+// DefaultTemplateDir is where templates named digest.<format>.tmpl replace
+// the built-in ones.
+func DefaultTemplateDir() string {
+	return filepath.Join(xdg.ConfigHome, "nned", "templates")
+}
+
+// New groups the articles by feed or category. Groups are ordered by name
+// and their articles newest first. When story is set, a story covered by
+// several feeds is listed once, as its newest article, with the other feeds
+// in Also.
+func New(title string, articles []c.Article, groupBy string, since, until time.Time, story func(key string) string) (Digest, error) {
+	if !slices.Contains(GroupBy, groupBy) {
+		return Digest{}, fmt.Errorf("unknown grouping %q, expected one of %s", groupBy, strings.Join(GroupBy, ", "))
+	}
+	d := Digest{
+		Title:   title,
+		Since:   since,
+		Until:   until,
+		GroupBy: groupBy,
+		Total:   len(articles),
+	}
+	articles = planet.Merge(articles, nil)
+	byName := make(map[string]*Group)
+	leads := make(map[string]*Article)
+	also := make(map[string][]string)
+	for _, a := range articles {
+		article := toArticle(a)
+		if story != nil {
+			s := story(store.Key(a))
+			if lead, ok := leads[s]; ok {
+				if article.Feed != lead.Feed && !slices.Contains(also[s], article.Feed) {
+					also[s] = append(also[s], article.Feed)
+				}
+				d.Total--
+				continue
+			}
+			leads[s] = &article
+		}
+		name := article.Feed
+		if groupBy == GroupByCategory {
+			name = article.Category
+			if name == "" {
+				name = "Uncategorized"
+			}
+		}
+		group, ok := byName[name]
+		if !ok {
+			group = &Group{Name: name}
+			byName[name] = group
+		}
+		group.Articles = append(group.Articles, article)
+	}
+	for _, group := range byName {
+		for i := range group.Articles {
+			if story != nil {
+				group.Articles[i].Also = also[story(group.Articles[i].key)]
+			}
+		}
+		slices.SortStableFunc(group.Articles, func(a, b Article) int {
+			return b.Date.Compare(a.Date)
+		})
+		d.Groups = append(d.Groups, *group)
+	}
+	slices.SortFunc(d.Groups, func(a, b Group) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return d, nil
+}
+
+func toArticle(a c.Article) Article {
+	article := Article{
+		key:      store.Key(a),
+		Title:    a.Title,
+		Link:     a.Link,
+		Feed:     a.SourceTitle,
+		Category: a.Category,
+		Tags:     a.Tags,
+		Author:   a.Author,
+		Starred:  a.Starred,
+	}
+	if article.Feed == "" {
+		article.Feed = a.Source
+	}
+	if a.Date != nil {
+		article.Date = *a.Date
+	}
+	summary, err := util.GetStringFromHTML(a.Description)
+	if err == nil {
+		article.Summary = truncate(strings.Join(strings.Fields(summary), " "), summaryLength)
+	}
+	return article
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
+
+// Render executes the template of a format. A digest.<format>.tmpl in dir
+// takes precedence over the built-in template. HTML templates escape what
+// they insert according to its context; the others insert it as is.
+func Render(w io.Writer, fs afero.Fs, dir string, format string, d Digest) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+	name := "digest." + format + ".tmpl"
+	source, err := afero.ReadFile(fs, filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		source, err = defaults.ReadFile("templates/" + name)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read template %s: %w", name, err)
+	}
+
+	if format == FormatHTML {
+		t, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Parse(string(source))
+		if err != nil {
+			return fmt.Errorf("invalid template %s: %w", name, err)
+		}
+		return t.Execute(w, d)
+	}
+	t, err := template.New(name).Funcs(funcs).Parse(string(source))
+	if err != nil {
+		return fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return t.Execute(w, d)
+}
+
+var funcs = template.FuncMap{
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Local().Format(layout)
+	},
+	"join":   strings.Join,
+	"md":     markdown,
+	"mdurl":  markdownURL,
+	"plural": plural,
+	"underline": func(char string, s string) string {
+		return strings.Repeat(char, len([]rune(s)))
+	},
+}
+
+var (
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "#", `\#`, "!", `\!`, "|", `\|`,
+		"[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "<", `\<`, ">", `\>`,
+	)
+	markdownURLEscaper = strings.NewReplacer(
+		" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E", `\`, "%5C", "\n", "", "\r", "",
+	)
+)
+
+// markdown escapes text from feeds so that it is shown as is on a single
+// line rather than read as markup.
+func markdown(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
+
+// markdownURL encodes the characters that would end a link destination.
+func markdownURL(s string) string {
+	return markdownURLEscaper.Replace(strings.TrimSpace(s))
+}
+
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
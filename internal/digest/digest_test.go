@@ -0,0 +1,219 @@
+package digest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/spf13/afero"
+)
+
+func at(hour int) *time.Time {
+	t := time.Date(2024, 3, 1, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+var articles = []c.Article{
+	{ID: "1", Title: "Go 1.24", Link: "https://go.dev/1", SourceTitle: "Go Blog", Category: "Go", Date: at(9)},
+	{ID: "2", Title: "Go 1.24 is out", Link: "https://hn/2", SourceTitle: "HN", Date: at(10)},
+	{ID: "3", Title: "Rust 1.85", Link: "https://rust/3", Source: "rust-lang.org", Category: "rust", Date: at(8), Description: "<p>Rust <b>release</b></p>"},
+	{ID: "4", Title: "Go tooling", Link: "https://go.dev/4", SourceTitle: "Go Blog", Category: "Go", Date: at(11)},
+}
+
+func names(d Digest) map[string][]string {
+	groups := make(map[string][]string)
+	for _, g := range d.Groups {
+		for _, a := range g.Articles {
+			groups[g.Name] = append(groups[g.Name], a.Title)
+		}
+	}
+	return groups
+}
+
+func TestNew(t *testing.T) {
+	story := func(key string) string {
+		if key == "2" {
+			return "1"
+		}
+		return key
+	}
+	tests := []struct {
+		name    string
+		groupBy string
+		story   func(key string) string
+		total   int
+		want    map[string][]string
+		order   []string
+	}{
+		{
+			"by feed", GroupByFeed, nil, 4,
+			map[string][]string{"Go Blog": {"Go tooling", "Go 1.24"}, "HN": {"Go 1.24 is out"}, "rust-lang.org": {"Rust 1.85"}},
+			[]string{"Go Blog", "HN", "rust-lang.org"},
+		},
+		{
+			"by category", GroupByCategory, nil, 4,
+			map[string][]string{"Go": {"Go tooling", "Go 1.24"}, "rust": {"Rust 1.85"}, "Uncategorized": {"Go 1.24 is out"}},
+			[]string{"Go", "rust", "Uncategorized"},
+		},
+		{
+			"stories", GroupByFeed, story, 3,
+			map[string][]string{"Go Blog": {"Go tooling"}, "HN": {"Go 1.24 is out"}, "rust-lang.org": {"Rust 1.85"}},
+			[]string{"Go Blog", "HN", "rust-lang.org"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New("Daily", articles, tt.groupBy, *at(0), *at(12), tt.story)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Total != tt.total {
+				t.Errorf("Total = %d, want %d", d.Total, tt.total)
+			}
+			if got := names(d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+			order := make([]string, len(d.Groups))
+			for i, g := range d.Groups {
+				order[i] = g.Name
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("group order = %v, want %v", order, tt.order)
+			}
+		})
+	}
+}
+
+func TestNewAlso(t *testing.T) {
+	story := func(string) string { return "same" }
+	d, err := New("Daily", articles[:3], GroupByFeed, *at(0), *at(12), story)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Groups) != 1 || len(d.Groups[0].Articles) != 1 {
+		t.Fatalf("groups = %v", names(d))
+	}
+	lead := d.Groups[0].Articles[0]
+	if lead.Title != "Go 1.24 is out" || !reflect.DeepEqual(lead.Also, []string{"Go Blog", "rust-lang.org"}) {
+		t.Errorf("lead = %q also %v", lead.Title, lead.Also)
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	long := c.Article{Title: "Long", Description: "<p>" + strings.Repeat("word ", 100) + "</p>"}
+	d, err := New("Daily", []c.Article{articles[2], long}, GroupByFeed, *at(0), *at(12), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range d.Groups {
+		for _, a := range g.Articles {
+			switch a.Title {
+			case "Rust 1.85":
+				if a.Summary != "Rust release" {
+					t.Errorf("Summary = %q", a.Summary)
+				}
+			case "Long":
+				if n := len([]rune(a.Summary)); n != summaryLength {
+					t.Errorf("summary has %d runes, want %d", n, summaryLength)
+				}
+			}
+		}
+	}
+}
+
+func TestNewUnknownGrouping(t *testing.T) {
+	if _, err := New("Daily", nil, "author", time.Time{}, time.Time{}, nil); err == nil {
+		t.Error("accepted an unknown grouping")
+	}
+}
+
+func render(t *testing.T, fs afero.Fs, format string, articles []c.Article) string {
+	t.Helper()
+	d, err := New("Daily", articles, GroupByFeed, *at(0), *at(12), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = Render(&buf, fs, "/templates", format, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRender(t *testing.T) {
+	hostile := []c.Article{{
+		ID:          "x",
+		Title:       "Click [here](https://evil)\n# pwned <script>",
+		Link:        "https://example.com/a (b)",
+		SourceTitle: "Feed *bold*",
+		Date:        at(9),
+	}}
+	tests := []struct {
+		format string
+		want   []string
+		reject []string
+	}{
+		{FormatMarkdown, []string{
+			`- [Click \[here\]\(https://evil\) \# pwned \<script\>](https://example.com/a%20%28b%29)`,
+			`## Feed \*bold\*`,
+		}, []string{"\n# pwned", "<script>"}},
+		{FormatHTML, []string{"&lt;script&gt;"}, []string{"<script>"}},
+		{FormatText, []string{"Click [here](https://evil)"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out := render(t, afero.NewMemMapFs(), tt.format, hostile)
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output lacks %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.reject {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderOverride(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/templates/digest.md.tmpl", []byte(`{{.Title}}: {{plural .Total "article" "articles"}}`), 0o644)
+	if out := render(t, fs, FormatMarkdown, articles[:1]); out != "Daily: 1 article" {
+		t.Errorf("output = %q", out)
+	}
+
+	afero.WriteFile(fs, "/templates/digest.txt.tmpl", []byte(`{{.Nope`), 0o644)
+	d, _ := New("Daily", nil, GroupByFeed, time.Time{}, time.Time{}, nil)
+	if err := Render(&bytes.Buffer{}, fs, "/templates", FormatText, d); err == nil {
+		t.Error("rendered an invalid template")
+	}
+	if err := Render(&bytes.Buffer{}, fs, "/templates", "pdf", d); err == nil {
+		t.Error("rendered an unknown format")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"plain title":        "plain title",
+		"a_b *c* `d`":        "a\\_b \\*c\\* \\`d\\`",
+		"[x](y)":             `\[x\]\(y\)`,
+		"line\nbreak\r\n# h": `line break \# h`,
+		`back\slash`:         `back\\slash`,
+		"<b>tag</b> | !":     `\<b\>tag\</b\> \| \!`,
+	}
+	for in, want := range tests {
+		if got := markdown(in); got != want {
+			t.Errorf("markdown(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := markdownURL(" https://example.com/a b(c)<d>\n "); got != "https://example.com/a%20b%28c%29%3Cd%3E" {
+		t.Errorf("markdownURL = %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/rules"
+	"nned/internal/store"
+)
+
+// StoredArticles returns the stored articles newer than the last date, with
+// the details of their feed from the current config and the rules applied.
+// Articles of feeds that are no longer configured and those hidden by a rule
+// are left out. They are ordered newest first, and clustered into stories
+// as they are loaded.
+func (m *Monitor) StoredArticles() []c.Article {
+	v := m.view()
+	articles := make([]c.Article, 0)
+	if v.store == nil {
+		return articles
+	}
+	for _, r := range v.store.Records() {
+		if a, ok := v.show(r); ok {
+			articles = append(articles, a)
+		}
+	}
+	return articles
+}
+
+// StoredArticle returns the article stored under key as StoredArticles
+// would, and false when it is not stored or left out.
+func (m *Monitor) StoredArticle(key string) (c.Article, bool) {
+	v := m.view()
+	if v.store == nil {
+		return c.Article{}, false
+	}
+	r, ok := v.store.Get(key)
+	if !ok {
+		return c.Article{}, false
+	}
+	return v.show(r)
+}
+
+// articleView is the part of the config that decides how stored articles
+// are shown.
+type articleView struct {
+	m        *Monitor
+	store    *store.Store
+	lastDate time.Time
+	engine   *rules.Engine
+	feeds    map[string]c.Feed
+}
+
+func (m *Monitor) view() articleView {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v := articleView{
+		m:        m,
+		store:    m.Config.Store,
+		lastDate: m.Config.LastDate,
+		engine:   m.Config.Rules,
+		feeds:    make(map[string]c.Feed, len(m.Config.Feeds)),
+	}
+	for _, feed := range m.Config.Feeds {
+		v.feeds[feed.Url] = feed
+	}
+	return v
+}
+
+func (v articleView) show(r store.Record) (c.Article, bool) {
+	a := r.Article
+	a.Starred = r.Starred
+	if a.Date == nil || a.Date.Before(v.lastDate) {
+		return c.Article{}, false
+	}
+	if a.FeedUrl != "" {
+		feed, ok := v.feeds[a.FeedUrl]
+		if !ok {
+			return c.Article{}, false
+		}
+		a.SourceTitle = feed.Title
+		if a.SourceTitle == "" {
+			a.SourceTitle = a.Source
+		}
+		a.SourceColor = feed.Color
+		a.Category = feed.Category
+		a.Tags = feed.Tags
+	}
+	v.m.addStory(a)
+	result := v.engine.Apply(a)
+	if result.Hide {
+		return c.Article{}, false
+	}
+	return result.Article, true
+}
+
+// Refresh makes every feed that is not being fetched due right away.
+func (m *Monitor) Refresh() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sched := range m.schedules {
+		if !sched.inFlight {
+			sched.next = time.Time{}
+		}
+	}
+}
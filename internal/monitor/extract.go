@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/monitor/fulltext"
+	"nned/internal/store"
+)
+
+const extractionQueue = 256
+
+type extraction struct {
+	article       c.Article
+	selector      string
+	versionVector int
+}
+
+// queueExtraction asks for the full text of an article of a fulltext feed
+// that has none yet. Pages are fetched one at a time and rate limited per
+// host, so this runs apart from the feed fetches. When the queue is full
+// the article is left for a later fetch of its feed.
+func (m *Monitor) queueExtraction(article c.Article, versionVector int) {
+	if article.Content != "" || article.Link == "" {
+		return
+	}
+	feed, ok := m.feed(article.FeedUrl)
+	if !ok || !feed.FullText {
+		return
+	}
+	key := store.Key(article)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.extracting[key] {
+		return
+	}
+	select {
+	case m.chanExtract <- extraction{article: article, selector: feed.Selector, versionVector: versionVector}:
+		m.extracting[key] = true
+	default:
+	}
+}
+
+// handleExtractions extracts queued articles and passes them on as updates
+// carrying the content.
+func (m *Monitor) handleExtractions() {
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case job := <-m.chanExtract:
+			content, err := m.extractor.Extract(m.ctx, job.article.Link, job.selector)
+			m.mu.Lock()
+			delete(m.extracting, store.Key(job.article))
+			m.mu.Unlock()
+			if err != nil {
+				m.reportError(c.FeedError{
+					Url:  job.article.Link,
+					Time: time.Now(),
+					Err:  fmt.Errorf("error extracting article: %w", err),
+				})
+				if !errors.Is(err, fulltext.ErrCache) {
+					continue
+				}
+			}
+			job.article.Content = content
+			select {
+			case m.chanUpdateArticle <- c.MessageUpdate[c.Article]{Data: job.article, VersionVector: job.versionVector}:
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (m *Monitor) feed(url string) (c.Feed, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, feed := range m.Config.Feeds {
+		if feed.Url == url {
+			return feed, true
+		}
+	}
+	return c.Feed{}, false
+}
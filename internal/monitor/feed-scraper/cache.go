@@ -0,0 +1,101 @@
+package feedscraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/afero"
+)
+
+type cacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// httpCache keeps the validators of the last successful response for each
+// feed URL so that later requests can be made conditional.
+type httpCache struct {
+	fs      afero.Fs
+	path    string
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+func DefaultCachePath() string {
+	return filepath.Join(xdg.CacheHome, "nned", "http.json")
+}
+
+func newHTTPCache(fs afero.Fs, path string) (*httpCache, error) {
+	cache := &httpCache{
+		fs:      fs,
+		path:    path,
+		entries: make(map[string]cacheEntry),
+	}
+	if fs == nil || path == "" {
+		return cache, nil
+	}
+
+	handle, err := fs.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, fmt.Errorf("unable to open http cache: %w", err)
+	}
+	defer handle.Close()
+
+	err = json.NewDecoder(handle).Decode(&cache.entries)
+	if err != nil {
+		cache.entries = make(map[string]cacheEntry)
+		return cache, fmt.Errorf("unable to read http cache: %w", err)
+	}
+	return cache, nil
+}
+
+func (h *httpCache) get(url string) (cacheEntry, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entry, ok := h.entries[url]
+	return entry, ok
+}
+
+func (h *httpCache) set(url string, entry cacheEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.entries[url] == entry {
+		return nil
+	}
+	if entry == (cacheEntry{}) {
+		delete(h.entries, url)
+	} else {
+		h.entries[url] = entry
+	}
+	return h.save()
+}
+
+func (h *httpCache) save() error {
+	if h.fs == nil || h.path == "" {
+		return nil
+	}
+	err := h.fs.MkdirAll(filepath.Dir(h.path), 0o755)
+	if err != nil {
+		return fmt.Errorf("unable to write http cache: %w", err)
+	}
+
+	tmp := h.path + ".tmp"
+	handle, err := h.fs.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to write http cache: %w", err)
+	}
+	err = json.NewEncoder(handle).Encode(h.entries)
+	handle.Close()
+	if err != nil {
+		return fmt.Errorf("unable to write http cache: %w", err)
+	}
+	return h.fs.Rename(tmp, h.path)
+}
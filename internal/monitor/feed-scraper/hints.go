@@ -0,0 +1,104 @@
+package feedscraper
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/rss"
+)
+
+const ttlKey = "ttl"
+
+// rssTranslator keeps the channel <ttl>, which the universal feed drops.
+type rssTranslator struct {
+	gofeed.DefaultRSSTranslator
+}
+
+func (t *rssTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	f, err := t.DefaultRSSTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+	if rssFeed, ok := feed.(*rss.Feed); ok && rssFeed.TTL != "" {
+		if f.Custom == nil {
+			f.Custom = make(map[string]string)
+		}
+		f.Custom[ttlKey] = rssFeed.TTL
+	}
+	return f, nil
+}
+
+// feedTTL returns the update period a feed declares for itself, either
+// through the RSS <ttl> element or the syndication module.
+func feedTTL(feed *gofeed.Feed) time.Duration {
+	if ttl, ok := feed.Custom[ttlKey]; ok {
+		minutes, err := strconv.Atoi(strings.TrimSpace(ttl))
+		if err == nil && minutes > 0 {
+			return time.Duration(minutes) * time.Minute
+		}
+	}
+
+	sy, ok := feed.Extensions["sy"]
+	if !ok {
+		return 0
+	}
+	var period time.Duration
+	if values := sy["updatePeriod"]; len(values) > 0 {
+		switch strings.TrimSpace(values[0].Value) {
+		case "hourly":
+			period = time.Hour
+		case "daily":
+			period = 24 * time.Hour
+		case "weekly":
+			period = 7 * 24 * time.Hour
+		case "monthly":
+			period = 30 * 24 * time.Hour
+		case "yearly":
+			period = 365 * 24 * time.Hour
+		}
+	}
+	if period == 0 {
+		return 0
+	}
+	if values := sy["updateFrequency"]; len(values) > 0 {
+		frequency, err := strconv.Atoi(strings.TrimSpace(values[0].Value))
+		if err == nil && frequency > 0 {
+			period /= time.Duration(frequency)
+		}
+	}
+	return period
+}
+
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+func maxAge(header http.Header) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package feedscraper
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+
+	"nned/internal/canonical"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// articleID identifies an item across fetches of the same feed. The GUID is
+// preferred, then the canonical link and finally the content itself, so that
+// an item without either changes identity when it is edited.
+func articleID(feedUrl string, item *gofeed.Item) string {
+	var source string
+	switch {
+	case item.GUID != "":
+		source = "guid:" + item.GUID
+	case item.Link != "":
+		source = "link:" + canonical.Link(item.Link)
+	default:
+		source = "hash:" + item.Title + "\x00" + item.Description + "\x00" + item.Content
+	}
+	sum := sha1.Sum([]byte(feedUrl + "\x00" + source))
+	return hex.EncodeToString(sum[:])
+}
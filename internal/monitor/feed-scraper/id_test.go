@@ -0,0 +1,73 @@
+package feedscraper
+
+import (
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestArticleID(t *testing.T) {
+	const feed = "https://example.com/feed.xml"
+	tests := []struct {
+		name string
+		a, b *gofeed.Item
+		same bool
+	}{
+		{
+			"guid wins over an edited link",
+			&gofeed.Item{GUID: "1", Link: "https://example.com/a", Title: "A"},
+			&gofeed.Item{GUID: "1", Link: "https://example.com/b", Title: "A, edited"},
+			true,
+		},
+		{
+			"different guids",
+			&gofeed.Item{GUID: "1", Link: "https://example.com/a"},
+			&gofeed.Item{GUID: "2", Link: "https://example.com/a"},
+			false,
+		},
+		{
+			"link without tracking parameters",
+			&gofeed.Item{Link: "https://example.com/a?utm_source=rss", Title: "A"},
+			&gofeed.Item{Link: "https://Example.com/a#top", Title: "A, edited"},
+			true,
+		},
+		{
+			"different links",
+			&gofeed.Item{Link: "https://example.com/a"},
+			&gofeed.Item{Link: "https://example.com/b"},
+			false,
+		},
+		{
+			"same content",
+			&gofeed.Item{Title: "A", Description: "d"},
+			&gofeed.Item{Title: "A", Description: "d"},
+			true,
+		},
+		{
+			"edited content",
+			&gofeed.Item{Title: "A", Description: "d"},
+			&gofeed.Item{Title: "A", Description: "d, edited"},
+			false,
+		},
+		{
+			"guid and link do not collide",
+			&gofeed.Item{GUID: "https://example.com/a"},
+			&gofeed.Item{Link: "https://example.com/a"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := articleID(feed, tt.a) == articleID(feed, tt.b); got != tt.same {
+				t.Errorf("same ID = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestArticleIDPerFeed(t *testing.T) {
+	item := &gofeed.Item{GUID: "1"}
+	if articleID("https://a.example/feed", item) == articleID("https://b.example/feed", item) {
+		t.Error("the same GUID in two feeds has the same ID")
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	c "nned/internal/common"
 
 	"github.com/mmcdole/gofeed"
+	"github.com/spf13/afero"
 )
 
 type Scraper struct {
@@ -20,20 +22,61 @@ type Scraper struct {
 	started            bool
 	chanError          chan error
 	chanUpdateArticle  chan c.MessageUpdate[c.Article]
-	chanRequestArticle chan []c.Feed
+	chanRequestArticle chan Request
+	chanFetchResult    chan FetchResult
+	chanBatchDone      chan struct{}
 	lastDate           time.Time
+	client             *http.Client
+	cache              *httpCache
 }
 
 type Config struct {
 	Ctx                context.Context
 	ChanUpdateArticle  chan c.MessageUpdate[c.Article]
-	ChanRequestArticle chan []c.Feed
+	ChanRequestArticle chan Request
 	ChanError          chan error
+	ChanFetchResult    chan FetchResult
+	ChanBatchDone      chan struct{}
 	LastDate           time.Time
+	Fs                 afero.Fs
+	CachePath          string
 }
 
+// Request asks the scraper to fetch feeds. Articles and fetch results
+// produced for it carry its VersionVector, so that results requested under
+// an older configuration can be told apart.
+type Request struct {
+	Feeds         []c.Feed
+	VersionVector int
+}
+
+// FetchResult describes the outcome of a single feed request. RetryAfter,
+// MaxAge and TTL are the polling hints given by the server and the feed.
+type FetchResult struct {
+	Feed       c.Feed
+	Time       time.Time
+	Latency    time.Duration
+	Status     int
+	Items      int
+	Err        error
+	RetryAfter time.Duration
+	MaxAge     time.Duration
+	TTL        time.Duration
+
+	VersionVector int
+}
+
+const userAgent = "nned (+https://github.com/kr0nei/nned)"
+
 func NewScraper(config Config) *Scraper {
 	ctx, cancel := context.WithCancel(config.Ctx)
+	cache, err := newHTTPCache(config.Fs, config.CachePath)
+	if err != nil {
+		select {
+		case config.ChanError <- err:
+		default:
+		}
+	}
 	return &Scraper{
 		ctx:                ctx,
 		numWorkers:         2,
@@ -41,7 +84,11 @@ func NewScraper(config Config) *Scraper {
 		chanError:          config.ChanError,
 		chanUpdateArticle:  config.ChanUpdateArticle,
 		chanRequestArticle: config.ChanRequestArticle,
+		chanFetchResult:    config.ChanFetchResult,
+		chanBatchDone:      config.ChanBatchDone,
 		lastDate:           config.LastDate,
+		client:             &http.Client{Timeout: 30 * time.Second},
+		cache:              cache,
 	}
 }
 
@@ -64,12 +111,25 @@ func (s *Scraper) Stop() error {
 	return nil
 }
 
+func (s *Scraper) LastDate() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastDate
+}
+
+func (s *Scraper) SetLastDate(lastDate time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastDate = lastDate
+}
+
 func (s *Scraper) handleScraping() {
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
-		case feeds := <-s.chanRequestArticle:
+		case request := <-s.chanRequestArticle:
+			feeds := request.Feeds
 			if len(feeds) == 0 {
 				continue
 			}
@@ -80,7 +140,7 @@ func (s *Scraper) handleScraping() {
 			wg.Add(numFeeds)
 
 			for w := 0; w < s.numWorkers; w++ {
-				go s.getNewArticles(jobs, results, s.chanError)
+				go s.getNewArticles(request.VersionVector, jobs, results, s.chanError)
 			}
 			for i := 0; i < numFeeds; i++ {
 				jobs <- feeds[i]
@@ -93,49 +153,147 @@ func (s *Scraper) handleScraping() {
 			}
 			for _, article := range articles {
 				s.chanUpdateArticle <- c.MessageUpdate[c.Article]{
-					Data: article,
+					Data:          article,
+					VersionVector: request.VersionVector,
 				}
 			}
+			if s.chanBatchDone != nil {
+				s.chanBatchDone <- struct{}{}
+			}
 		}
 	}
 }
 
-func (s *Scraper) getNewArticles(jobs <-chan c.Feed, results chan<- []c.Article, errors chan<- error) {
+func (s *Scraper) getNewArticles(versionVector int, jobs <-chan c.Feed, results chan<- []c.Article, errors chan<- error) {
 	fp := gofeed.NewParser()
+	fp.RSSTranslator = &rssTranslator{}
+	lastDate := s.LastDate()
 
-	var articles []c.Article
-	articles = make([]c.Article, 0)
 	for job := range jobs {
-		feed, err := fp.ParseURL(job.Url)
+		result := FetchResult{
+			Feed:          job,
+			Time:          time.Now(),
+			VersionVector: versionVector,
+		}
+		feed, err := s.fetch(fp, job.Url, &result)
+		result.Latency = time.Since(result.Time)
+		result.Err = err
 		if err != nil {
+			s.report(result)
+			results <- nil
+			errors <- c.FeedError{
+				Url:  job.Url,
+				Time: time.Now(),
+				Err:  fmt.Errorf("error parsing feed: %w", err),
+			}
+			continue
+		}
+		if feed == nil {
+			s.report(result)
 			results <- nil
-			errors <- fmt.Errorf("error parsing feed %s", job.Url)
 			continue
 		}
+		result.Items = len(feed.Items)
+		result.TTL = feedTTL(feed)
+		s.report(result)
+
+		sourceTitle := job.Title
+		if sourceTitle == "" {
+			sourceTitle = feed.Title
+		}
+		articles := make([]c.Article, 0)
 		for _, item := range feed.Items {
 			if item.PublishedParsed == nil {
 				continue
 			}
+			if item.PublishedParsed.After(time.Now()) || !item.PublishedParsed.After(lastDate) {
+				continue
+			}
+			author := ""
+			if len(item.Authors) > 0 && item.Authors[0] != nil {
+				author = item.Authors[0].Name
+			}
+			var updated *time.Time
+			if item.UpdatedParsed != nil && item.UpdatedParsed.After(*item.PublishedParsed) {
+				updated = item.UpdatedParsed
+			}
 			articles = append(articles, c.Article{
+				ID:          articleID(job.Url, item),
 				Title:       item.Title,
 				Description: item.Description,
 				Link:        item.Link,
+				Author:      author,
 				Date:        item.PublishedParsed,
+				Updated:     updated,
 				Source:      feed.Title,
-				SourceTitle: job.Title,
+				FeedUrl:     job.Url,
+				SourceTitle: sourceTitle,
 				SourceColor: job.Color,
+				Category:    job.Category,
+				Tags:        job.Tags,
 			})
 		}
-		{
-			var tmp []c.Article
-			tmp = make([]c.Article, 0)
-			for _, article := range articles {
-				if !article.Date.After(time.Now()) && article.Date.After(s.lastDate) {
-					tmp = append(tmp, article)
-				}
-			}
-			articles = tmp
-		}
 		results <- articles
 	}
 }
+
+// fetch downloads and parses a feed. Requests are made conditional on the
+// validators of the previous response; a nil feed means the server answered
+// 304 Not Modified and there is nothing new to parse. The HTTP status and
+// caching hints of the response are recorded in result.
+func (s *Scraper) fetch(fp *gofeed.Parser, url string, result *FetchResult) (*gofeed.Feed, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if entry, ok := s.cache.get(url); ok {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.RetryAfter = retryAfter(resp.Header, time.Now())
+	result.MaxAge = maxAge(resp.Header)
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	feed, err := fp.Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.cache.set(url, cacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	})
+	if err != nil {
+		s.chanError <- c.FeedError{Url: url, Time: time.Now(), Err: err}
+	}
+	return feed, nil
+}
+
+func (s *Scraper) report(result FetchResult) {
+	if s.chanFetchResult == nil {
+		return
+	}
+	select {
+	case s.chanFetchResult <- result:
+	case <-s.ctx.Done():
+	}
+}
@@ -0,0 +1,142 @@
+package feedscraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/spf13/afero"
+)
+
+const testCachePath = "/cache/nned/http.json"
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example</title>
+  <entry>
+    <id>urn:1</id>
+    <title>Unchanged</title>
+    <link href="https://example.com/1"/>
+    <published>2024-03-01T12:00:00Z</published>
+    <updated>2024-03-01T12:00:00Z</updated>
+  </entry>
+  <entry>
+    <id>urn:2</id>
+    <title>Edited</title>
+    <link href="https://example.com/2"/>
+    <published>2024-03-01T12:00:00Z</published>
+    <updated>2024-03-02T08:00:00Z</updated>
+  </entry>
+</feed>`
+
+func newTestScraper(t *testing.T, fs afero.Fs) *Scraper {
+	t.Helper()
+	return NewScraper(Config{
+		Ctx:             t.Context(),
+		ChanError:       make(chan error, 4),
+		ChanFetchResult: make(chan FetchResult, 4),
+		Fs:              fs,
+		CachePath:       testCachePath,
+	})
+}
+
+// scrape fetches a single feed the way a worker does and returns the
+// articles and the reported result.
+func scrape(t *testing.T, s *Scraper, url string) ([]c.Article, FetchResult) {
+	t.Helper()
+	jobs := make(chan c.Feed, 1)
+	jobs <- c.Feed{Url: url}
+	close(jobs)
+	results := make(chan []c.Article, 1)
+	s.getNewArticles(0, jobs, results, make(chan error, 1))
+	return <-results, <-s.chanFetchResult
+}
+
+func TestScrapeUpdated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, atomFeed)
+	}))
+	defer srv.Close()
+
+	articles, result := scrape(t, newTestScraper(t, afero.NewMemMapFs()), srv.URL)
+	if result.Err != nil || len(articles) != 2 {
+		t.Fatalf("got %d articles, %v", len(articles), result.Err)
+	}
+	if articles[0].Updated != nil {
+		t.Errorf("Updated = %v, want none for an entry updated when published", articles[0].Updated)
+	}
+	want := time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)
+	if articles[1].Updated == nil || !articles[1].Updated.Equal(want) {
+		t.Errorf("Updated = %v, want the feed's %v", articles[1].Updated, want)
+	}
+}
+
+// conditionalServer serves atomFeed with validators and answers 304 Not
+// Modified to requests that send them back. It records the validators of
+// each request.
+func conditionalServer(t *testing.T) (*httptest.Server, *[]cacheEntry) {
+	t.Helper()
+	const etag, modified = `"v1"`, "Sat, 02 Mar 2024 08:00:00 GMT"
+	var mu sync.Mutex
+	sent := make([]cacheEntry, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, cacheEntry{ETag: r.Header.Get("If-None-Match"), LastModified: r.Header.Get("If-Modified-Since")})
+		mu.Unlock()
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", modified)
+		fmt.Fprint(w, atomFeed)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &sent
+}
+
+func TestScrapeConditional(t *testing.T) {
+	srv, sent := conditionalServer(t)
+	s := newTestScraper(t, afero.NewMemMapFs())
+
+	articles, result := scrape(t, s, srv.URL)
+	if result.Err != nil || result.Status != http.StatusOK || len(articles) != 2 {
+		t.Fatalf("first fetch: status %d, %d articles, %v", result.Status, len(articles), result.Err)
+	}
+	articles, result = scrape(t, s, srv.URL)
+	if result.Err != nil || result.Status != http.StatusNotModified {
+		t.Fatalf("second fetch: status %d, %v", result.Status, result.Err)
+	}
+	if len(articles) != 0 || result.Items != 0 {
+		t.Errorf("304 passed on %d articles and %d items, want the stored ones kept", len(articles), result.Items)
+	}
+
+	want := []cacheEntry{{}, {ETag: `"v1"`, LastModified: "Sat, 02 Mar 2024 08:00:00 GMT"}}
+	if !reflect.DeepEqual(*sent, want) {
+		t.Errorf("validators sent = %+v, want %+v", *sent, want)
+	}
+}
+
+func TestScrapeConditionalAfterRestart(t *testing.T) {
+	srv, sent := conditionalServer(t)
+	fs := afero.NewMemMapFs()
+
+	if _, result := scrape(t, newTestScraper(t, fs), srv.URL); result.Status != http.StatusOK {
+		t.Fatalf("first fetch: status %d, %v", result.Status, result.Err)
+	}
+	if _, err := fs.Stat(testCachePath); err != nil {
+		t.Fatalf("validators not saved: %v", err)
+	}
+	if _, result := scrape(t, newTestScraper(t, fs), srv.URL); result.Status != http.StatusNotModified {
+		t.Errorf("fetch after restart: status %d, want 304", result.Status)
+	}
+	if got := (*sent)[1].ETag; got != `"v1"` {
+		t.Errorf("If-None-Match after restart = %q", got)
+	}
+}
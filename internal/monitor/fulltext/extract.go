@@ -0,0 +1,156 @@
+package fulltext
+
+import (
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var (
+	unlikely = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|share|newsletter|promo`)
+	maybe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negative = regexp.MustCompile(`(?i)hidden|^hid$|banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|nav`)
+)
+
+const minParagraph = 25
+
+// extract finds the main content of an HTML page, in the spirit of
+// Readability: paragraphs give points to their parent and grandparent,
+// class and id names add or remove weight, and link heavy candidates are
+// penalised. Relative links and images are made absolute.
+func extract(r io.Reader, base *url.URL, selector string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return "", err
+	}
+	absolutize(doc, base)
+
+	if selector != "" {
+		parts := make([]string, 0)
+		doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+			if h, err := goquery.OuterHtml(s); err == nil {
+				parts = append(parts, h)
+			}
+		})
+		if len(parts) == 0 {
+			return "", ErrNoContent
+		}
+		return strings.Join(parts, "\n"), nil
+	}
+
+	doc.Find("script, style, noscript, iframe, form, nav, aside, footer, header, svg, button, input").Remove()
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("html, body, article, main") {
+			return
+		}
+		id, _ := s.Attr("id")
+		class, _ := s.Attr("class")
+		match := id + " " + class
+		if unlikely.MatchString(match) && !maybe.MatchString(match) {
+			s.Remove()
+		}
+	})
+
+	scores := make(map[*html.Node]float64)
+	candidates := make([]*goquery.Selection, 0)
+	doc.Find("p, pre, td, blockquote").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < minParagraph {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+		parent := p.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		for level, ancestor := range []*goquery.Selection{parent, parent.Parent()} {
+			if ancestor.Length() == 0 {
+				continue
+			}
+			node := ancestor.Get(0)
+			if _, ok := scores[node]; !ok {
+				scores[node] = classWeight(ancestor)
+				candidates = append(candidates, ancestor)
+			}
+			if level == 0 {
+				scores[node] += score
+			} else {
+				scores[node] += score / 2
+			}
+		}
+	})
+
+	var best *goquery.Selection
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		score := scores[candidate.Get(0)] * (1 - linkDensity(candidate))
+		if best == nil || score > bestScore {
+			best = candidate
+			bestScore = score
+		}
+	}
+	if best == nil {
+		best = doc.Find("article, main").First()
+	}
+	if best == nil || best.Length() == 0 {
+		return "", ErrNoContent
+	}
+
+	content, err := goquery.OuterHtml(best)
+	if err != nil {
+		return "", err
+	}
+	return content, nil
+}
+
+func classWeight(s *goquery.Selection) float64 {
+	weight := 0.0
+	for _, name := range []string{"class", "id"} {
+		value, ok := s.Attr(name)
+		if !ok || value == "" {
+			continue
+		}
+		if negative.MatchString(value) {
+			weight -= 25
+		}
+		if positive.MatchString(value) {
+			weight += 25
+		}
+	}
+	if s.Is("article, main") {
+		weight += 10
+	}
+	return weight
+}
+
+func linkDensity(s *goquery.Selection) float64 {
+	total := len(s.Text())
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len(a.Text())
+	})
+	return float64(links) / float64(total)
+}
+
+func absolutize(doc *goquery.Document, base *url.URL) {
+	for _, attr := range []string{"href", "src"} {
+		doc.Find("[" + attr + "]").Each(func(_ int, s *goquery.Selection) {
+			value, _ := s.Attr(attr)
+			ref, err := url.Parse(value)
+			if err != nil {
+				return
+			}
+			s.SetAttr(attr, base.ResolveReference(ref).String())
+		})
+	}
+}
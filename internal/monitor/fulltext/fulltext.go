@@ -0,0 +1,196 @@
+package fulltext
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/afero"
+)
+
+const (
+	userAgent    = "nned (+https://github.com/kr0nei/nned)"
+	hostInterval = 2 * time.Second
+	maxBody      = 5 << 20
+
+	// The disk cache keeps pages for cacheAge and at most cacheSize bytes,
+	// dropping the oldest first.
+	cacheAge  = 30 * 24 * time.Hour
+	cacheSize = 50 << 20
+)
+
+// Extractor downloads article pages and extracts their main content.
+// Results are cached by URL, in memory and optionally on disk, and
+// requests to the same host are spaced out by hostInterval.
+type Extractor struct {
+	client *http.Client
+	fs     afero.Fs
+	dir    string
+	mu     sync.Mutex
+	memory map[string]string
+	next   map[string]time.Time
+}
+
+func DefaultCacheDir() string {
+	return filepath.Join(xdg.CacheHome, "nned", "fulltext")
+}
+
+func New(fs afero.Fs, dir string) *Extractor {
+	return &Extractor{
+		client: &http.Client{Timeout: 30 * time.Second},
+		fs:     fs,
+		dir:    dir,
+		memory: make(map[string]string),
+		next:   make(map[string]time.Time),
+	}
+}
+
+// Extract returns the main content of the page at link as HTML. When
+// selector is set it is used instead of the content heuristic. If the
+// content cannot be written to the disk cache, it is returned along with an
+// error wrapping ErrCache.
+func (e *Extractor) Extract(ctx context.Context, link string, selector string) (string, error) {
+	key := cacheKey(link, selector)
+	if content, ok := e.cached(key); ok {
+		return content, nil
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid article link %q", link)
+	}
+	err = e.wait(ctx, u.Host)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unable to fetch article: %s", resp.Status)
+	}
+
+	content, err := extract(http.MaxBytesReader(nil, resp.Body, maxBody), u, selector)
+	if err != nil {
+		return "", err
+	}
+	err = e.store(key, content)
+	if err != nil {
+		return content, fmt.Errorf("%w: %w", ErrCache, err)
+	}
+	return content, nil
+}
+
+// wait blocks until a request to host is allowed.
+func (e *Extractor) wait(ctx context.Context, host string) error {
+	e.mu.Lock()
+	now := time.Now()
+	at := e.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	e.next[host] = at.Add(hostInterval)
+	e.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Extractor) cached(key string) (string, bool) {
+	e.mu.Lock()
+	content, ok := e.memory[key]
+	e.mu.Unlock()
+	if ok || e.fs == nil || e.dir == "" {
+		return content, ok
+	}
+
+	data, err := afero.ReadFile(e.fs, filepath.Join(e.dir, key+".html"))
+	if err != nil {
+		return "", false
+	}
+	e.mu.Lock()
+	e.memory[key] = string(data)
+	e.mu.Unlock()
+	return string(data), true
+}
+
+func (e *Extractor) store(key, content string) error {
+	e.mu.Lock()
+	e.memory[key] = content
+	e.mu.Unlock()
+	if e.fs == nil || e.dir == "" {
+		return nil
+	}
+	err := e.fs.MkdirAll(e.dir, 0o755)
+	if err != nil {
+		return err
+	}
+	err = afero.WriteFile(e.fs, filepath.Join(e.dir, key+".html"), []byte(content), 0o644)
+	if err != nil {
+		return err
+	}
+	return e.prune(time.Now(), cacheAge, cacheSize)
+}
+
+// prune removes cached pages older than maxAge, then the oldest ones until
+// the cache fits in maxSize bytes.
+func (e *Extractor) prune(now time.Time, maxAge time.Duration, maxSize int64) error {
+	infos, err := afero.ReadDir(e.fs, e.dir)
+	if err != nil {
+		return err
+	}
+	infos = slices.DeleteFunc(infos, func(info os.FileInfo) bool {
+		return info.IsDir() || !strings.HasSuffix(info.Name(), ".html")
+	})
+	slices.SortFunc(infos, func(a, b os.FileInfo) int {
+		return b.ModTime().Compare(a.ModTime())
+	})
+
+	size := int64(0)
+	for _, info := range infos {
+		size += info.Size()
+		if now.Sub(info.ModTime()) <= maxAge && size <= maxSize {
+			continue
+		}
+		err := e.fs.Remove(filepath.Join(e.dir, info.Name()))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func cacheKey(link, selector string) string {
+	sum := sha1.Sum([]byte(selector + "\x00" + link))
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	ErrNoContent = errors.New("no article content found")
+	ErrCache     = errors.New("unable to cache article")
+)
@@ -0,0 +1,198 @@
+package fulltext
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const testCacheDir = "/cache/nned/fulltext"
+
+const page = `<html><body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<div class="post">
+  <p>The first paragraph of the post, long enough to count, with a comma.</p>
+  <p>A second paragraph follows, and it links to <a href="/next">the next post</a>.</p>
+</div>
+<div class="sidebar"><p>Subscribe to the newsletter for more posts like this one.</p></div>
+</body></html>`
+
+// serve starts a server for page that counts its requests. It answers
+// with 500 Internal Server Error while down is set.
+func serve(t *testing.T, down *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	hits := new(atomic.Int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if down != nil && down.Load() {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func TestExtract(t *testing.T) {
+	server, _ := serve(t, nil)
+	tests := []struct {
+		name     string
+		selector string
+		want     []string
+		unwanted []string
+	}{
+		{"heuristic", "", []string{`class="post"`, "first paragraph", `href="` + server.URL + `/next"`}, []string{"Home", "newsletter"}},
+		{"selector", "div.sidebar", []string{`class="sidebar"`, "newsletter"}, []string{"first paragraph"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(nil, "")
+			content, err := e.Extract(t.Context(), server.URL+"/post", tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("content %q does not contain %q", content, want)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(content, unwanted) {
+					t.Errorf("content %q contains %q", content, unwanted)
+				}
+			}
+		})
+	}
+
+	_, err := New(nil, "").Extract(t.Context(), server.URL+"/post", "div.missing")
+	if !errors.Is(err, ErrNoContent) {
+		t.Errorf("missing selector: got %v, want ErrNoContent", err)
+	}
+}
+
+func TestExtractCache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	server, hits := serve(t, nil)
+	link := server.URL + "/post"
+
+	e := New(fs, testCacheDir)
+	first, err := e.Extract(t.Context(), link, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory, err := e.Extract(t.Context(), link, "")
+	if err != nil || memory != first {
+		t.Errorf("memory cache: got %q, %v", memory, err)
+	}
+
+	disk, err := New(fs, testCacheDir).Extract(t.Context(), link, "")
+	if err != nil || disk != first {
+		t.Errorf("disk cache: got %q, %v", disk, err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("page fetched %d times, want 1", n)
+	}
+
+	e.next = make(map[string]time.Time)
+	_, err = e.Extract(t.Context(), link, "div.post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("page fetched %d times, want 2 after a new selector", n)
+	}
+}
+
+func TestExtractFailure(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	down := new(atomic.Bool)
+	server, hits := serve(t, down)
+
+	cached := server.URL + "/cached"
+	content, err := New(fs, testCacheDir).Extract(t.Context(), cached, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down.Store(true)
+	e := New(fs, testCacheDir)
+	got, err := e.Extract(t.Context(), cached, "")
+	if err != nil || got != content {
+		t.Errorf("cached page while the site is down: got %q, %v", got, err)
+	}
+
+	link := server.URL + "/post"
+	_, err = e.Extract(t.Context(), link, "")
+	if err == nil {
+		t.Fatal("expected an error while the site is down")
+	}
+	if _, ok := e.cached(cacheKey(link, "")); ok {
+		t.Error("failed fetch was cached")
+	}
+
+	down.Store(false)
+	e.next = make(map[string]time.Time)
+	_, err = e.Extract(t.Context(), link, "")
+	if err != nil {
+		t.Errorf("fetch after the site recovered: %v", err)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("page fetched %d times, want 3", n)
+	}
+}
+
+func TestExtractCacheError(t *testing.T) {
+	server, _ := serve(t, nil)
+	e := New(afero.NewReadOnlyFs(afero.NewMemMapFs()), testCacheDir)
+	content, err := e.Extract(t.Context(), server.URL+"/post", "")
+	if !errors.Is(err, ErrCache) {
+		t.Errorf("got %v, want ErrCache", err)
+	}
+	if !strings.Contains(content, "first paragraph") {
+		t.Errorf("content not returned with the cache error: %q", content)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"new.html", 40, time.Hour},
+		{"recent.html", 40, 2 * time.Hour},
+		{"older.html", 40, 3 * time.Hour},
+		{"expired.html", 10, 40 * 24 * time.Hour},
+		{"other.txt", 100, 40 * 24 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(testCacheDir, f.name)
+		if err := afero.WriteFile(fs, path, make([]byte, f.size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fs.Chtimes(path, now.Add(-f.age), now.Add(-f.age))
+	}
+
+	e := New(fs, testCacheDir)
+	if err := e.prune(now, 24*time.Hour, 100); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		_, err := fs.Stat(filepath.Join(testCacheDir, f.name))
+		kept := err == nil
+		want := f.name == "new.html" || f.name == "recent.html" || f.name == "other.txt"
+		if kept != want {
+			t.Errorf("%s kept = %v, want %v", f.name, kept, want)
+		}
+	}
+}
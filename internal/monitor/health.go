@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"net/http"
+	"time"
+
+	c "nned/internal/common"
+	feedscraper "nned/internal/monitor/feed-scraper"
+)
+
+type Health int
+
+const (
+	HealthUnknown Health = iota
+	HealthOK
+	HealthDegraded
+	HealthFailing
+)
+
+const failingThreshold = 3
+
+func (h Health) String() string {
+	switch h {
+	case HealthOK:
+		return "ok"
+	case HealthDegraded:
+		return "degraded"
+	case HealthFailing:
+		return "failing"
+	default:
+		return "unknown"
+	}
+}
+
+type FeedStatus struct {
+	Feed                c.Feed
+	LastAttempt         time.Time
+	LastSuccess         time.Time
+	LastStatus          int
+	LastError           error
+	ConsecutiveFailures int
+	ItemCount           int
+	AvgLatency          time.Duration
+	NextFetch           time.Time
+	fetches             int
+}
+
+func (s FeedStatus) Health() Health {
+	switch {
+	case s.LastAttempt.IsZero():
+		return HealthUnknown
+	case s.ConsecutiveFailures >= failingThreshold:
+		return HealthFailing
+	case s.ConsecutiveFailures > 0:
+		return HealthDegraded
+	default:
+		return HealthOK
+	}
+}
+
+func (s *FeedStatus) record(result feedscraper.FetchResult) {
+	s.LastAttempt = result.Time
+	s.LastStatus = result.Status
+	s.LastError = result.Err
+	s.fetches++
+	s.AvgLatency += (result.Latency - s.AvgLatency) / time.Duration(s.fetches)
+
+	if result.Err != nil {
+		s.ConsecutiveFailures++
+		return
+	}
+	s.ConsecutiveFailures = 0
+	s.LastSuccess = result.Time
+	if result.Status != http.StatusNotModified {
+		s.ItemCount = result.Items
+	}
+}
+
+// FeedStatus returns the health record of every configured feed, in the
+// order the feeds are configured.
+func (m *Monitor) FeedStatus() []FeedStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	statuses := make([]FeedStatus, 0, len(m.Config.Feeds))
+	for _, feed := range m.Config.Feeds {
+		status := FeedStatus{Feed: feed}
+		if s, ok := m.health[feed.Url]; ok {
+			status = *s
+			status.Feed = feed
+		}
+		if sched, ok := m.schedules[feed.Url]; ok && !sched.inFlight {
+			status.NextFetch = sched.next
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (m *Monitor) recordFetch(result feedscraper.FetchResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.health[result.Feed.Url]
+	if !ok {
+		status = &FeedStatus{Feed: result.Feed}
+		m.health[result.Feed.Url] = status
+	}
+	status.record(result)
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+	feedscraper "nned/internal/monitor/feed-scraper"
+)
+
+func TestFeedStatusRecord(t *testing.T) {
+	feed := c.Feed{Url: testFeed}
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("connection refused")
+	steps := []struct {
+		name     string
+		result   feedscraper.FetchResult
+		health   Health
+		failures int
+		items    int
+		success  int
+	}{
+		{"fetched", feedscraper.FetchResult{Status: http.StatusOK, Items: 10, Latency: 100 * time.Millisecond}, HealthOK, 0, 10, 0},
+		{"failed once", feedscraper.FetchResult{Err: failure, Latency: 300 * time.Millisecond}, HealthDegraded, 1, 10, 0},
+		{"failed twice", feedscraper.FetchResult{Status: http.StatusBadGateway, Err: failure, Latency: 200 * time.Millisecond}, HealthDegraded, 2, 10, 0},
+		{"failing", feedscraper.FetchResult{Err: failure, Latency: 200 * time.Millisecond}, HealthFailing, 3, 10, 0},
+		{"not modified", feedscraper.FetchResult{Status: http.StatusNotModified, Latency: 200 * time.Millisecond}, HealthOK, 0, 10, 4},
+		{"fetched again", feedscraper.FetchResult{Status: http.StatusOK, Items: 12, Latency: 200 * time.Millisecond}, HealthOK, 0, 12, 5},
+	}
+
+	m, _ := NewMonitor(Config{Feeds: []c.Feed{feed}})
+	defer m.Stop()
+	if got := m.FeedStatus()[0].Health(); got != HealthUnknown {
+		t.Fatalf("health before the first fetch = %s, want unknown", got)
+	}
+	for i, step := range steps {
+		step.result.Feed = feed
+		step.result.Time = start.Add(time.Duration(i) * time.Minute)
+		m.recordFetch(step.result)
+
+		status := m.FeedStatus()[0]
+		if status.Health() != step.health || status.ConsecutiveFailures != step.failures || status.ItemCount != step.items {
+			t.Errorf("%s: %s with %d failures and %d items, want %s with %d and %d", step.name,
+				status.Health(), status.ConsecutiveFailures, status.ItemCount, step.health, step.failures, step.items)
+		}
+		if want := start.Add(time.Duration(step.success) * time.Minute); !status.LastSuccess.Equal(want) {
+			t.Errorf("%s: last success %v, want %v", step.name, status.LastSuccess, want)
+		}
+		if !status.LastAttempt.Equal(step.result.Time) || status.LastStatus != step.result.Status || status.LastError != step.result.Err {
+			t.Errorf("%s: last attempt %v, status %d, error %v", step.name, status.LastAttempt, status.LastStatus, status.LastError)
+		}
+	}
+	if got := m.FeedStatus()[0].AvgLatency; got != 200*time.Millisecond {
+		t.Errorf("average latency = %v, want 200ms", got)
+	}
+}
@@ -3,18 +3,30 @@ package monitor
 import (
 	"context"
 	"errors"
-	"fmt"
 	"sync"
 	"time"
 
+	"nned/internal/cluster"
 	c "nned/internal/common"
 	feedscraper "nned/internal/monitor/feed-scraper"
+	"nned/internal/monitor/fulltext"
+	"nned/internal/rules"
+	"nned/internal/store"
+
+	"github.com/spf13/afero"
 )
 
 type Config struct {
 	RefreshInterval int
+	MaxBackoff      int
 	Feeds           []c.Feed
 	LastDate        time.Time
+	Store           *store.Store
+	Rules           *rules.Engine
+	Cluster         c.Cluster
+	Fs              afero.Fs
+	CachePath       string
+	FullTextDir     string
 }
 
 type Monitor struct {
@@ -23,29 +35,48 @@ type Monitor struct {
 	ctx                  context.Context
 	mu                   sync.RWMutex
 	chanUpdateArticle    chan c.MessageUpdate[c.Article]
-	chanRequestArticle   chan []c.Feed
+	chanRequestArticle   chan feedscraper.Request
 	chanError            chan error
+	chanFetchResult      chan feedscraper.FetchResult
+	chanBatchDone        chan struct{}
+	health               map[string]*FeedStatus
+	schedules            map[string]*schedule
 	onUpdateArticle      func(article c.Article, versionVector int)
+	onError              func(err error)
+	onNotify             func(result rules.Result)
+	loaded               map[string]bool
 	articleVersionVector int
 	scraper              *feedscraper.Scraper
+	extractor            *fulltext.Extractor
+	chanExtract          chan extraction
+	extracting           map[string]bool
+	stories              *cluster.Index
 }
 
 type ConfigUpdateFunc struct {
 	OnUpdateArticle func(article c.Article, versionVector int)
+	OnError         func(err error)
+	OnNotify        func(result rules.Result)
 }
 
 func NewMonitor(config Config) (*Monitor, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	chanError := make(chan error, 5)
 	chanUpdateArticle := make(chan c.MessageUpdate[c.Article], 2)
-	chanRequestArticle := make(chan []c.Feed, 2)
+	chanRequestArticle := make(chan feedscraper.Request, 2)
+	chanFetchResult := make(chan feedscraper.FetchResult, 16)
+	chanBatchDone := make(chan struct{}, 1)
 
 	feedScraper := feedscraper.NewScraper(feedscraper.Config{
 		Ctx:                ctx,
 		ChanUpdateArticle:  chanUpdateArticle,
 		ChanRequestArticle: chanRequestArticle,
 		ChanError:          chanError,
+		ChanFetchResult:    chanFetchResult,
+		ChanBatchDone:      chanBatchDone,
 		LastDate:           config.LastDate,
+		Fs:                 config.Fs,
+		CachePath:          config.CachePath,
 	})
 
 	return &Monitor{
@@ -55,26 +86,38 @@ func NewMonitor(config Config) (*Monitor, error) {
 		chanUpdateArticle:  chanUpdateArticle,
 		chanRequestArticle: chanRequestArticle,
 		chanError:          chanError,
+		chanFetchResult:    chanFetchResult,
+		chanBatchDone:      chanBatchDone,
+		health:             make(map[string]*FeedStatus),
+		schedules:          make(map[string]*schedule),
+		loaded:             make(map[string]bool),
 		scraper:            feedScraper,
+		extractor:          fulltext.New(config.Fs, config.FullTextDir),
+		chanExtract:        make(chan extraction, extractionQueue),
+		extracting:         make(map[string]bool),
+		stories:            newStories(config.Cluster),
 	}, nil
 }
 
 func (m *Monitor) Start() {
 	go m.handleUpdates()
+	go m.handleExtractions()
 	m.scraper.Start()
-	ticker := time.NewTicker(time.Duration(m.Config.RefreshInterval) * time.Second)
+	m.chanRequestArticle <- m.dueFeeds(time.Now())
+	ticker := time.NewTicker(time.Second)
 	go func() {
 		for {
 			select {
-			case <-ticker.C:
-				m.chanRequestArticle <- m.Config.Feeds
+			case now := <-ticker.C:
+				if due := m.dueFeeds(now); len(due.Feeds) > 0 {
+					m.chanRequestArticle <- due
+				}
 			case <-m.ctx.Done():
 				ticker.Stop()
 				return
 			}
 		}
 	}()
-	m.chanRequestArticle <- m.Config.Feeds
 }
 
 func (m *Monitor) SetOnUpdate(config ConfigUpdateFunc) error {
@@ -82,22 +125,169 @@ func (m *Monitor) SetOnUpdate(config ConfigUpdateFunc) error {
 		return errors.New("onUpdateArticle must be set ")
 	}
 	m.onUpdateArticle = config.OnUpdateArticle
+	m.onError = config.OnError
+	m.onNotify = config.OnNotify
 	return nil
 }
 
+// Reconfigure replaces the feeds and polling settings of a running monitor.
+// The version vector is bumped so that articles and fetch results still in
+// flight for the old configuration are discarded, and every feed is fetched
+// again. Articles before the new last date are dropped from the stories. It
+// returns the new version vector.
+func (m *Monitor) Reconfigure(config Config) int {
+	m.mu.Lock()
+	m.Config.RefreshInterval = config.RefreshInterval
+	m.Config.MaxBackoff = config.MaxBackoff
+	m.Config.Feeds = config.Feeds
+	m.Config.LastDate = config.LastDate
+	m.Config.Rules = config.Rules
+	if config.Cluster != m.Config.Cluster {
+		m.Config.Cluster = config.Cluster
+		m.stories = newStories(config.Cluster)
+	}
+	stories := m.stories
+	m.schedules = make(map[string]*schedule)
+	m.articleVersionVector++
+	versionVector := m.articleVersionVector
+	m.mu.Unlock()
+
+	if stories != nil {
+		stories.Evict(config.LastDate)
+	}
+	m.scraper.SetLastDate(config.LastDate)
+	return versionVector
+}
+
+func (m *Monitor) VersionVector() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.articleVersionVector
+}
+
 func (m *Monitor) handleUpdates() {
 	for {
 		select {
 		case <-m.ctx.Done():
 			return
 		case update := <-m.chanUpdateArticle:
-			if update.VersionVector != m.articleVersionVector {
+			m.handleArticle(update)
+		case err := <-m.chanError:
+			m.reportError(err)
+		case result := <-m.chanFetchResult:
+			if result.VersionVector != m.VersionVector() {
 				continue
 			}
-			go m.onUpdateArticle(update.Data, update.VersionVector)
-		case err := <-m.chanError:
-			fmt.Printf("error %+v\n", err)
+			m.recordFetch(result)
+			m.reschedule(result)
+		case <-m.chanBatchDone:
+			m.drainArticles()
+			m.markLoaded()
+		}
+	}
+}
+
+func (m *Monitor) handleArticle(update c.MessageUpdate[c.Article]) {
+	if update.VersionVector != m.VersionVector() {
+		return
+	}
+	article, ok := m.process(update.Data)
+	if !ok {
+		return
+	}
+	m.queueExtraction(article, update.VersionVector)
+	go m.onUpdateArticle(article, update.VersionVector)
+}
+
+// drainArticles handles the articles of a batch that are still buffered
+// when the batch is reported done.
+func (m *Monitor) drainArticles() {
+	for {
+		select {
+		case update := <-m.chanUpdateArticle:
+			m.handleArticle(update)
+		default:
+			return
+		}
+	}
+}
+
+// markLoaded records the feeds that have been fetched successfully at least
+// once. The first articles of a feed are what it already had, so
+// notifications only start after them; a feed that failed so far has not
+// delivered those yet.
+func (m *Monitor) markLoaded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for url, status := range m.health {
+		if !status.LastSuccess.IsZero() {
+			m.loaded[url] = true
+		}
+	}
+}
+
+func (m *Monitor) isLoaded(url string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.loaded[url]
+}
+
+// process stores an article and runs the rules on it. The returned article
+// is the stored version with the changes of the rules; it is false when a
+// rule hides the article. Read and starred state are only set by rules the
+// first time an article is seen, so that they can be changed by hand. New
+// articles of feeds that were fetched before are passed on to be notified.
+func (m *Monitor) process(article c.Article) (c.Article, bool) {
+	article, isNew := m.storeArticle(article)
+	m.addStory(article)
+	result := m.Rules().Apply(article)
+	if isNew && m.Config.Store != nil {
+		key := store.Key(article)
+		if result.MarkRead {
+			m.reportIf(m.Config.Store.SetRead(key, true))
 		}
+		if result.Star {
+			m.reportIf(m.Config.Store.SetStarred(key, true))
+		}
+	}
+	if isNew && m.onNotify != nil && m.isLoaded(article.FeedUrl) {
+		go m.onNotify(result)
+	}
+	return result.Article, !result.Hide
+}
+
+// storeArticle records the article and returns its stored version, which
+// carries the updated marker when the feed has edited it. The bool is true
+// when the article was not stored before.
+func (m *Monitor) storeArticle(article c.Article) (c.Article, bool) {
+	if m.Config.Store == nil {
+		return article, true
+	}
+	r, isNew, err := m.Config.Store.Put(article)
+	m.reportIf(err)
+	r.Article.Starred = r.Starred
+	return r.Article, isNew
+}
+
+func (m *Monitor) Rules() *rules.Engine {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Config.Rules
+}
+
+func (m *Monitor) reportIf(err error) {
+	if err != nil {
+		m.reportError(err)
+	}
+}
+
+func (m *Monitor) reportError(err error) {
+	var feedErr c.FeedError
+	if !errors.As(err, &feedErr) {
+		feedErr = c.FeedError{Time: time.Now(), Err: err}
+	}
+	if m.onError != nil {
+		go m.onError(feedErr)
 	}
 }
 
@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+	feedscraper "nned/internal/monitor/feed-scraper"
+	"nned/internal/notify"
+	"nned/internal/store"
+
+	"github.com/spf13/afero"
+)
+
+const testFeed = "https://example.com/feed.atom"
+
+type stubSender struct {
+	mu   sync.Mutex
+	sent []notify.Notification
+	done chan struct{}
+}
+
+func (s *stubSender) Send(n notify.Notification) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, n)
+	s.mu.Unlock()
+	s.done <- struct{}{}
+	return nil
+}
+
+func TestNotifyAfterFirstSuccess(t *testing.T) {
+	st, err := store.New(afero.NewMemMapFs(), "/articles.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	sender := &stubSender{done: make(chan struct{}, 4)}
+	notifier, err := notify.NewWithSender(c.Notify{Enabled: true, Batch: 1, MinInterval: 1}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notifier.Close()
+
+	feed := c.Feed{Url: testFeed}
+	m, _ := NewMonitor(Config{Feeds: []c.Feed{feed}, Store: st})
+	defer m.Stop()
+	m.SetOnUpdate(ConfigUpdateFunc{
+		OnUpdateArticle: func(c.Article, int) {},
+		OnNotify:        notifier.Add,
+	})
+	now := time.Now()
+	article := func(id string) c.Article {
+		return c.Article{ID: id, Title: "Article " + id, FeedUrl: testFeed, Date: &now}
+	}
+
+	m.recordFetch(feedscraper.FetchResult{Feed: feed, Time: now, Err: errors.New("unreachable")})
+	m.markLoaded()
+	if m.isLoaded(testFeed) {
+		t.Fatal("feed that only failed is loaded")
+	}
+
+	m.recordFetch(feedscraper.FetchResult{Feed: feed, Time: now, Status: 200, Items: 1})
+	m.process(article("backlog"))
+	m.markLoaded()
+	if !m.isLoaded(testFeed) {
+		t.Fatal("feed is not loaded after a successful fetch")
+	}
+	m.process(article("backlog"))
+	m.process(article("new"))
+
+	select {
+	case <-sender.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification sent")
+	}
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	if n := sender.sent[0]; n.Count != 1 || n.Articles[0].ID != "new" {
+		t.Errorf("notified %d articles %v, want only the new one", n.Count, n.Articles)
+	}
+}
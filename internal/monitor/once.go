@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"errors"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/store"
+)
+
+// Once fetches every configured feed a single time and returns the
+// deduplicated articles when all feeds have reported. It must not be
+// combined with Start.
+func (m *Monitor) Once() ([]c.Article, error) {
+	err := m.scraper.Start()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Stop()
+
+	articles := make([]c.Article, 0)
+	seen := make(map[string]bool)
+	errs := make([]error, 0)
+	collect := func(article c.Article) {
+		key := store.Key(article)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if article, ok := m.process(article); ok {
+			articles = append(articles, article)
+		}
+	}
+
+	request := m.dueFeeds(time.Now())
+	if len(request.Feeds) == 0 {
+		return articles, nil
+	}
+	m.chanRequestArticle <- request
+	for {
+		select {
+		case update := <-m.chanUpdateArticle:
+			collect(update.Data)
+		case err := <-m.chanError:
+			errs = append(errs, err)
+		case result := <-m.chanFetchResult:
+			m.recordFetch(result)
+			m.reschedule(result)
+		case <-m.chanBatchDone:
+			for {
+				select {
+				case update := <-m.chanUpdateArticle:
+					collect(update.Data)
+				case err := <-m.chanError:
+					errs = append(errs, err)
+				case result := <-m.chanFetchResult:
+					m.recordFetch(result)
+				default:
+					return articles, errors.Join(errs...)
+				}
+			}
+		case <-m.ctx.Done():
+			return articles, m.ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+)
+
+func TestOnce(t *testing.T) {
+	published := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	entry := func(id, title string) string {
+		return fmt.Sprintf(`<entry><id>%s</id><title>%s</title><link href="https://example.com/%s"/><published>%s</published></entry>`,
+			id, title, id, published)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Example</title>`+
+			entry("urn:1", "First")+entry("urn:2", "Second")+entry("urn:1", "First")+`</feed>`)
+	}))
+	defer srv.Close()
+
+	feeds := []c.Feed{
+		{Url: srv.URL + "/feed", Title: "Example"},
+		{Url: srv.URL + "/broken"},
+	}
+	m, _ := NewMonitor(Config{Feeds: feeds, LastDate: time.Now().Add(-24 * time.Hour)})
+	articles, err := m.Once()
+	if err == nil {
+		t.Error("the broken feed was not reported")
+	}
+
+	titles := make([]string, 0, len(articles))
+	for _, a := range articles {
+		titles = append(titles, a.Title)
+	}
+	slices.Sort(titles)
+	if !slices.Equal(titles, []string{"First", "Second"}) {
+		t.Errorf("articles = %v, want First and Second once each", titles)
+	}
+
+	statuses := m.FeedStatus()
+	if statuses[0].Health() != HealthOK || statuses[0].ItemCount != 3 {
+		t.Errorf("working feed: %s with %d items", statuses[0].Health(), statuses[0].ItemCount)
+	}
+	if statuses[1].Health() != HealthDegraded || statuses[1].LastStatus != http.StatusInternalServerError {
+		t.Errorf("broken feed: %s with status %d", statuses[1].Health(), statuses[1].LastStatus)
+	}
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"math/rand/v2"
+	"time"
+
+	c "nned/internal/common"
+	feedscraper "nned/internal/monitor/feed-scraper"
+)
+
+const defaultMaxBackoff = time.Hour
+
+type schedule struct {
+	next     time.Time
+	inFlight bool
+	ttl      time.Duration
+}
+
+// dueFeeds requests the feeds whose next fetch is at or before now and marks
+// them as in flight until their result is recorded.
+func (m *Monitor) dueFeeds(now time.Time) feedscraper.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := make([]c.Feed, 0)
+	for _, feed := range m.Config.Feeds {
+		sched, ok := m.schedules[feed.Url]
+		if !ok {
+			sched = &schedule{}
+			m.schedules[feed.Url] = sched
+		}
+		if sched.inFlight || sched.next.After(now) {
+			continue
+		}
+		sched.inFlight = true
+		due = append(due, feed)
+	}
+	return feedscraper.Request{
+		Feeds:         due,
+		VersionVector: m.articleVersionVector,
+	}
+}
+
+func (m *Monitor) reschedule(result feedscraper.FetchResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sched, ok := m.schedules[result.Feed.Url]
+	if !ok {
+		sched = &schedule{}
+		m.schedules[result.Feed.Url] = sched
+	}
+	if result.TTL > 0 {
+		sched.ttl = result.TTL
+	}
+	failures := 0
+	if status, ok := m.health[result.Feed.Url]; ok {
+		failures = status.ConsecutiveFailures
+	}
+	sched.inFlight = false
+	sched.next = result.Time.Add(m.nextDelay(result, sched.ttl, failures))
+}
+
+// nextDelay picks the wait before the next fetch of a feed. Healthy feeds
+// are polled at their own interval unless the feed or the server asks for
+// less, failing feeds back off exponentially with jitter.
+func (m *Monitor) nextDelay(result feedscraper.FetchResult, ttl time.Duration, failures int) time.Duration {
+	base := time.Duration(m.Config.RefreshInterval) * time.Second
+	if result.Feed.Interval > 0 {
+		base = time.Duration(result.Feed.Interval) * time.Second
+	}
+	maxBackoff := time.Duration(m.Config.MaxBackoff) * time.Second
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	delay := max(base, ttl, result.MaxAge)
+	if failures > 0 {
+		backoff := maxBackoff
+		if failures < 32 && base<<failures > 0 {
+			backoff = min(base<<failures, maxBackoff)
+		}
+		delay = backoff/2 + rand.N(backoff/2+1)
+	}
+	return max(delay, result.RetryAfter)
+}
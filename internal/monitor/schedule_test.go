@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+	feedscraper "nned/internal/monitor/feed-scraper"
+)
+
+func TestNextDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		result   feedscraper.FetchResult
+		ttl      time.Duration
+		failures int
+		wantMin  time.Duration
+		wantMax  time.Duration
+	}{
+		{
+			name:    "refresh interval",
+			config:  Config{RefreshInterval: 300},
+			wantMin: 5 * time.Minute, wantMax: 5 * time.Minute,
+		},
+		{
+			name:    "feed interval",
+			config:  Config{RefreshInterval: 300},
+			result:  feedscraper.FetchResult{Feed: c.Feed{Interval: 60}},
+			wantMin: time.Minute, wantMax: time.Minute,
+		},
+		{
+			name:    "ttl",
+			config:  Config{RefreshInterval: 300},
+			ttl:     time.Hour,
+			wantMin: time.Hour, wantMax: time.Hour,
+		},
+		{
+			name:    "max-age",
+			config:  Config{RefreshInterval: 300},
+			result:  feedscraper.FetchResult{MaxAge: 10 * time.Minute},
+			wantMin: 10 * time.Minute, wantMax: 10 * time.Minute,
+		},
+		{
+			name:     "first failure",
+			config:   Config{RefreshInterval: 60},
+			failures: 1,
+			wantMin:  time.Minute, wantMax: 2 * time.Minute,
+		},
+		{
+			name:     "third failure",
+			config:   Config{RefreshInterval: 60},
+			failures: 3,
+			wantMin:  4 * time.Minute, wantMax: 8 * time.Minute,
+		},
+		{
+			name:     "capped backoff",
+			config:   Config{RefreshInterval: 60, MaxBackoff: 600},
+			failures: 10,
+			wantMin:  5 * time.Minute, wantMax: 10 * time.Minute,
+		},
+		{
+			name:     "default cap",
+			config:   Config{RefreshInterval: 60},
+			failures: 100,
+			wantMin:  defaultMaxBackoff / 2, wantMax: defaultMaxBackoff,
+		},
+		{
+			name:     "retry-after",
+			config:   Config{RefreshInterval: 60},
+			result:   feedscraper.FetchResult{RetryAfter: 3 * time.Hour},
+			failures: 1,
+			wantMin:  3 * time.Hour, wantMax: 3 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Monitor{Config: tt.config}
+			for range 20 {
+				got := m.nextDelay(tt.result, tt.ttl, tt.failures)
+				if got < tt.wantMin || got > tt.wantMax {
+					t.Fatalf("nextDelay = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"nned/internal/cluster"
+	c "nned/internal/common"
+	"nned/internal/store"
+)
+
+func newStories(config c.Cluster) *cluster.Index {
+	if !config.Enabled {
+		return nil
+	}
+	return cluster.NewIndex(config.Threshold)
+}
+
+// addStory clusters an article into the stories covered by several feeds
+// when clustering is enabled.
+func (m *Monitor) addStory(article c.Article) {
+	m.mu.RLock()
+	stories := m.stories
+	m.mu.RUnlock()
+	if stories != nil {
+		stories.Add(store.Key(article), article)
+	}
+}
+
+// Story returns the key of the article that leads the story of the article
+// stored under key. Without clustering every article is its own story.
+func (m *Monitor) Story(key string) string {
+	m.mu.RLock()
+	stories := m.stories
+	m.mu.RUnlock()
+	if stories == nil {
+		return key
+	}
+	return stories.Story(key)
+}
+
+// Stories returns Story when stories are clustered and nil otherwise, the
+// form the exporters take it in.
+func (m *Monitor) Stories() func(key string) string {
+	if !m.Clustering() {
+		return nil
+	}
+	return m.Story
+}
+
+// Clustering reports whether stories are clustered.
+func (m *Monitor) Clustering() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.stories != nil
+}
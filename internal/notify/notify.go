@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/query"
+	"nned/internal/rules"
+)
+
+const (
+	MethodBell   = "bell"
+	MethodOSC9   = "osc9"
+	MethodOSC777 = "osc777"
+	MethodExec   = "exec"
+
+	DefaultBatch       = 5
+	DefaultMinInterval = 30
+
+	maxListed = 3
+)
+
+var Methods = []string{MethodBell, MethodOSC9, MethodOSC777, MethodExec}
+
+// Notification summarizes the articles of one batch.
+type Notification struct {
+	Title    string
+	Body     string
+	Link     string
+	Count    int
+	Articles []c.Article
+}
+
+// Sender delivers notifications. Senders are swapped out for stubs in
+// tests with NewWithSender.
+type Sender interface {
+	Send(n Notification) error
+}
+
+// Notifier collects new articles and sends them in batches. Articles that
+// arrive within the batch window of the first one are sent together, and
+// at most one notification is sent per minimum interval.
+type Notifier struct {
+	mu          sync.Mutex
+	w           io.Writer
+	custom      Sender
+	sender      Sender
+	enabled     bool
+	filter      query.Query
+	batch       time.Duration
+	minInterval time.Duration
+	pending     []c.Article
+	timer       *time.Timer
+	last        time.Time
+	onError     func(err error)
+}
+
+// New creates a notifier that writes terminal notifications to w.
+func New(config c.Notify, w io.Writer) (*Notifier, error) {
+	n := &Notifier{w: w}
+	return n, n.Configure(config)
+}
+
+func NewWithSender(config c.Notify, sender Sender) (*Notifier, error) {
+	n := &Notifier{custom: sender}
+	return n, n.Configure(config)
+}
+
+// Configure replaces the settings of the notifier. Pending articles are
+// kept and sent with the new settings.
+func (n *Notifier) Configure(config c.Notify) error {
+	filter, err := query.Parse(config.Filter)
+	if err != nil {
+		return fmt.Errorf("filter: %w", err)
+	}
+	sender := n.custom
+	if sender == nil {
+		sender, err = NewSender(config, n.w)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sender = sender
+	n.enabled = config.Enabled
+	n.filter = filter
+	n.batch = seconds(config.Batch, DefaultBatch)
+	n.minInterval = seconds(config.MinInterval, DefaultMinInterval)
+	return nil
+}
+
+func (n *Notifier) SetOnError(onError func(err error)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onError = onError
+}
+
+// Add queues a new article with the state the rules left it in. It is only
+// sent when notifications are enabled and the article matches the filter,
+// unless a rule with the notify action matched it.
+func (n *Notifier) Add(result rules.Result) {
+	a := result.Article
+	target := query.Target{Article: &a, Text: a.Description, Unread: !result.MarkRead, Starred: result.Star}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !result.Notify && (!n.enabled || !n.filter.Match(target)) {
+		return
+	}
+	n.pending = append(n.pending, a)
+	if n.timer != nil {
+		return
+	}
+	delay := n.batch
+	if wait := time.Until(n.last.Add(n.minInterval)); wait > delay {
+		delay = wait
+	}
+	n.timer = time.AfterFunc(delay, n.flush)
+}
+
+// Close drops pending articles.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+	n.pending = nil
+}
+
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	articles := n.pending
+	sender := n.sender
+	onError := n.onError
+	n.pending = nil
+	n.timer = nil
+	n.last = time.Now()
+	n.mu.Unlock()
+
+	if len(articles) == 0 {
+		return
+	}
+	err := sender.Send(summarize(articles))
+	if err != nil && onError != nil {
+		onError(fmt.Errorf("unable to send notification: %w", err))
+	}
+}
+
+func summarize(articles []c.Article) Notification {
+	n := Notification{
+		Count:    len(articles),
+		Articles: articles,
+		Link:     articles[0].Link,
+	}
+	if len(articles) == 1 {
+		a := articles[0]
+		n.Title = a.SourceTitle
+		if n.Title == "" {
+			n.Title = a.Source
+		}
+		n.Body = a.Title
+		return n
+	}
+
+	n.Title = fmt.Sprintf("%d new articles", len(articles))
+	titles := make([]string, 0, maxListed+1)
+	for _, a := range articles[:min(len(articles), maxListed)] {
+		titles = append(titles, a.Title)
+	}
+	if len(articles) > maxListed {
+		titles = append(titles, fmt.Sprintf("and %d more", len(articles)-maxListed))
+	}
+	n.Body = strings.Join(titles, "\n")
+	return n
+}
+
+func seconds(value, fallback int) time.Duration {
+	if value <= 0 {
+		value = fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
+// Validate checks a notify config without creating a notifier.
+func Validate(config c.Notify) error {
+	_, err := New(config, io.Discard)
+	return err
+}
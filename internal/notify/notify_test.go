@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/rules"
+)
+
+type stubSender struct {
+	mu   sync.Mutex
+	sent []Notification
+	done chan struct{}
+}
+
+func (s *stubSender) Send(n Notification) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, n)
+	s.mu.Unlock()
+	s.done <- struct{}{}
+	return nil
+}
+
+func TestAdd(t *testing.T) {
+	article := c.Article{Title: "Go 1.24", Description: "Generic type aliases", SourceTitle: "Go Blog"}
+	tests := []struct {
+		name   string
+		config c.Notify
+		result rules.Result
+		want   bool
+	}{
+		{"disabled", c.Notify{}, rules.Result{}, false},
+		{"enabled", c.Notify{Enabled: true}, rules.Result{}, true},
+		{"forced by a rule", c.Notify{}, rules.Result{Notify: true}, true},
+		{"title filter", c.Notify{Enabled: true, Filter: "go"}, rules.Result{}, true},
+		{"description filter", c.Notify{Enabled: true, Filter: "aliases"}, rules.Result{}, true},
+		{"feed filter", c.Notify{Enabled: true, Filter: "feed:lobsters"}, rules.Result{}, false},
+		{"unread", c.Notify{Enabled: true, Filter: "is:unread"}, rules.Result{}, true},
+		{"marked read by a rule", c.Notify{Enabled: true, Filter: "is:unread"}, rules.Result{MarkRead: true}, false},
+		{"starred by a rule", c.Notify{Enabled: true, Filter: "is:starred"}, rules.Result{Star: true}, true},
+		{"not starred", c.Notify{Enabled: true, Filter: "is:starred"}, rules.Result{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewWithSender(tt.config, &stubSender{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer n.Close()
+			tt.result.Article = article
+			n.Add(tt.result)
+			if got := len(n.pending) == 1; got != tt.want {
+				t.Errorf("queued = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	sender := &stubSender{done: make(chan struct{}, 1)}
+	n, err := NewWithSender(c.Notify{Enabled: true, Batch: 1}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+	n.Add(rules.Result{Article: c.Article{Title: "A", Link: "https://example.com/a"}})
+	n.Add(rules.Result{Article: c.Article{Title: "B"}})
+
+	select {
+	case <-sender.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification sent")
+	}
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d notifications, want one batch", len(sender.sent))
+	}
+	if got := sender.sent[0]; got.Count != 2 || got.Title != "2 new articles" || got.Link != "https://example.com/a" {
+		t.Errorf("notification = %+v", got)
+	}
+}
+
+func TestConfigureInvalidFilter(t *testing.T) {
+	_, err := NewWithSender(c.Notify{Filter: "is:new"}, &stubSender{})
+	if err == nil {
+		t.Error("accepted an invalid filter")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	articles := func(titles ...string) []c.Article {
+		list := make([]c.Article, len(titles))
+		for i, title := range titles {
+			list[i] = c.Article{Title: title, Source: "example.com"}
+		}
+		return list
+	}
+	tests := []struct {
+		name  string
+		list  []c.Article
+		title string
+		body  string
+	}{
+		{"one", articles("A"), "example.com", "A"},
+		{"one with feed title", []c.Article{{Title: "A", Source: "example.com", SourceTitle: "Example"}}, "Example", "A"},
+		{"several", articles("A", "B", "C"), "3 new articles", "A\nB\nC"},
+		{"many", articles("A", "B", "C", "D", "E"), "5 new articles", "A\nB\nC\nand 2 more"},
+	}
+	for _, tt := range tests {
+		n := summarize(tt.list)
+		if n.Title != tt.title || n.Body != tt.body || n.Count != len(tt.list) {
+			t.Errorf("%s: summarize = %q %q %d", tt.name, n.Title, n.Body, n.Count)
+		}
+	}
+}
+
+func TestTerminalSenders(t *testing.T) {
+	t.Setenv("TMUX", "")
+	n := Notification{Title: "Feed;x", Body: "New\narticle\a"}
+	tests := map[string]string{
+		MethodBell:   "\a",
+		MethodOSC9:   "\x1b]9;Feed,x: New article\a",
+		MethodOSC777: "\x1b]777;notify;Feed,x;New article\a",
+	}
+	for method, want := range tests {
+		var buf bytes.Buffer
+		sender, err := NewSender(c.Notify{Method: method}, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sender.Send(n); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s wrote %q, want %q", method, buf.String(), want)
+		}
+	}
+	if _, err := NewSender(c.Notify{Method: "pigeon"}, nil); err == nil {
+		t.Error("accepted an unknown method")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	cmd, err := NewCommand(`notify-send --app-name=nned "{{.Title}}" '{{.Body}} ({{.Count}})'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	cmd.run = func(name string, args ...string) error {
+		got = append([]string{name}, args...)
+		return nil
+	}
+	err = cmd.Send(Notification{Title: "Two words", Body: "A title", Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"notify-send", "--app-name=nned", "Two words", "A title (1)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{}, false},
+		{"a b\tc", []string{"a", "b", "c"}, false},
+		{`a "b c" 'd e'`, []string{"a", "b c", "d e"}, false},
+		{`a "" b`, []string{"a", "", "b"}, false},
+		{`x"y z"`, []string{"xy z"}, false},
+		{`"it's"`, []string{"it's"}, false},
+		{`"open`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitArgs(%q) error = %v", tt.input, err)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+	if _, err := NewCommand("   "); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("NewCommand of blanks error = %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	c "nned/internal/common"
+)
+
+const defaultCommand = `notify-send --app-name=nned "{{.Title}}" "{{.Body}}"`
+
+// NewSender returns the sender for the configured method. Terminal methods
+// write their escape sequence to w.
+func NewSender(config c.Notify, w io.Writer) (Sender, error) {
+	switch config.Method {
+	case "", MethodBell:
+		return terminal{w: w, format: func(Notification) string { return "\a" }}, nil
+	case MethodOSC9:
+		return terminal{w: w, format: func(n Notification) string {
+			return "\x1b]9;" + clean(n.Title+": "+n.Body) + "\a"
+		}}, nil
+	case MethodOSC777:
+		return terminal{w: w, format: func(n Notification) string {
+			return "\x1b]777;notify;" + clean(n.Title) + ";" + clean(n.Body) + "\a"
+		}}, nil
+	case MethodExec:
+		command := config.Command
+		if command == "" {
+			command = defaultCommand
+		}
+		return NewCommand(command)
+	}
+	return nil, fmt.Errorf("unknown method %q, expected one of %s", config.Method, strings.Join(Methods, ", "))
+}
+
+type terminal struct {
+	w      io.Writer
+	format func(n Notification) string
+}
+
+func (t terminal) Send(n Notification) error {
+	seq := t.format(n)
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err := io.WriteString(t.w, seq)
+	return err
+}
+
+// clean keeps notification text from ending the escape sequence early.
+func clean(s string) string {
+	return strings.NewReplacer("\a", "", "\x1b", "", ";", ",", "\n", " ").Replace(s)
+}
+
+// Command runs an external program for every notification. Each argument
+// of the command line is a text/template executed with the Notification,
+// so that titles with spaces stay a single argument.
+type Command struct {
+	args []*template.Template
+	run  func(name string, args ...string) error
+}
+
+func NewCommand(command string) (*Command, error) {
+	fields, err := splitArgs(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("empty notify command")
+	}
+	cmd := &Command{run: run}
+	for _, field := range fields {
+		t, err := template.New("arg").Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notify command: %w", err)
+		}
+		cmd.args = append(cmd.args, t)
+	}
+	return cmd, nil
+}
+
+func (c *Command) Send(n Notification) error {
+	args := make([]string, 0, len(c.args))
+	for _, t := range c.args {
+		var buf bytes.Buffer
+		err := t.Execute(&buf, n)
+		if err != nil {
+			return err
+		}
+		args = append(args, buf.String())
+	}
+	return c.run(args[0], args[1:]...)
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return err
+}
+
+// splitArgs splits a command line on whitespace outside of single or double
+// quotes and removes the quotes.
+func splitArgs(s string) ([]string, error) {
+	args := make([]string, 0)
+	var sb strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in notify command")
+	}
+	if inArg {
+		args = append(args, sb.String())
+	}
+	return args, nil
+}
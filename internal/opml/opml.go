@@ -0,0 +1,129 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type Document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Head    Head      `xml:"head"`
+	Body    []Outline `xml:"body>outline"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Entry is a feed outline flattened out of its folders. Category is the
+// path of the enclosing folders joined with "/".
+type Entry struct {
+	Title    string
+	Url      string
+	Category string
+}
+
+func Parse(r io.Reader) (Document, error) {
+	var doc Document
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return doc, fmt.Errorf("invalid opml: %w", err)
+	}
+	return doc, nil
+}
+
+func (d Document) Entries() []Entry {
+	entries := make([]Entry, 0)
+	var walk func(outlines []Outline, folders []string)
+	walk = func(outlines []Outline, folders []string) {
+		for _, o := range outlines {
+			title := o.Title
+			if title == "" {
+				title = o.Text
+			}
+			if o.XMLURL != "" {
+				entries = append(entries, Entry{
+					Title:    title,
+					Url:      o.XMLURL,
+					Category: strings.Join(folders, "/"),
+				})
+			}
+			if len(o.Outlines) > 0 {
+				walk(o.Outlines, append(folders[:len(folders):len(folders)], title))
+			}
+		}
+	}
+	walk(d.Body, nil)
+	return entries
+}
+
+// New builds an OPML 2.0 document, nesting entries into one folder per
+// category level.
+func New(title string, entries []Entry) Document {
+	doc := Document{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+	for _, e := range entries {
+		outlines := &doc.Body
+		if e.Category != "" {
+			for _, folder := range strings.Split(e.Category, "/") {
+				outlines = folderOutlines(outlines, folder)
+			}
+		}
+		text := e.Title
+		if text == "" {
+			text = e.Url
+		}
+		*outlines = append(*outlines, Outline{
+			Text:   text,
+			Title:  e.Title,
+			Type:   "rss",
+			XMLURL: e.Url,
+		})
+	}
+	return doc
+}
+
+func (d Document) Write(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(d)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func folderOutlines(outlines *[]Outline, folder string) *[]Outline {
+	for i := range *outlines {
+		o := &(*outlines)[i]
+		if o.XMLURL == "" && o.Text == folder {
+			return &o.Outlines
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: folder, Title: folder})
+	return &(*outlines)[len(*outlines)-1].Outlines
+}
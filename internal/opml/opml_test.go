@@ -0,0 +1,76 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nested = `<?xml version="1.0"?>
+<opml version="2.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Top" xmlUrl="https://top.example/feed"/>
+    <outline text="Tech">
+      <outline text="Go" title="Go Blog" xmlUrl="https://go.dev/blog/feed.atom"/>
+      <outline text="Languages">
+        <outline text="Rust" xmlUrl="https://blog.rust-lang.org/feed.xml"/>
+      </outline>
+    </outline>
+    <outline text="Empty folder"/>
+  </body>
+</opml>`
+
+func TestEntries(t *testing.T) {
+	doc, err := Parse(strings.NewReader(nested))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Title: "Top", Url: "https://top.example/feed"},
+		{Title: "Go Blog", Url: "https://go.dev/blog/feed.atom", Category: "Tech"},
+		{Title: "Rust", Url: "https://blog.rust-lang.org/feed.xml", Category: "Tech/Languages"},
+	}
+	if got := doc.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{"", "<opml", "not xml"} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded", input)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Title: "Top", Url: "https://top.example/feed"},
+		{Title: "Go Blog", Url: "https://go.dev/blog/feed.atom", Category: "Tech"},
+		{Title: "Rust", Url: "https://blog.rust-lang.org/feed.xml", Category: "Tech/Languages"},
+		{Title: "Zig", Url: "https://ziglang.org/news/index.xml", Category: "Tech/Languages"},
+		{Url: "https://untitled.example/feed", Category: "Tech"},
+	}
+	var buf bytes.Buffer
+	err := New("nned", entries).Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "2.0" || doc.Head.Title != "nned" {
+		t.Errorf("head = %q %+v", doc.Version, doc.Head)
+	}
+	if len(doc.Body) != 2 {
+		t.Errorf("body has %d outlines, want the feed and one folder", len(doc.Body))
+	}
+	want := append([]Entry(nil), entries...)
+	want[4].Title = want[4].Url
+	if got := doc.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+}
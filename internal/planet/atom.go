@@ -0,0 +1,125 @@
+package planet
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"time"
+
+	c "nned/internal/common"
+)
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle"`
+	Updated   string      `xml:"updated"`
+	Author    atomPerson  `xml:"author"`
+	Links     []atomLink  `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomSource struct {
+	ID    string     `xml:"id,omitempty"`
+	Title string     `xml:"title,omitempty"`
+	Links []atomLink `xml:"link"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+	Source     *atomSource    `xml:"source"`
+}
+
+func writeAtom(w io.Writer, meta Meta, articles []c.Article) error {
+	feed := atomFeed{
+		ID:        "urn:nned:feed:" + url.PathEscape(meta.Title),
+		Title:     meta.Title,
+		Subtitle:  meta.Description,
+		Updated:   lastModified(articles).UTC().Format(time.RFC3339),
+		Author:    atomPerson{Name: meta.Title},
+		Generator: "nned",
+		Entries:   make([]atomEntry, 0, len(articles)),
+	}
+	if meta.Link != "" {
+		feed.ID = meta.Link
+		feed.Links = []atomLink{{Rel: "self", Type: "application/atom+xml", Href: meta.Link}}
+	}
+	for _, a := range articles {
+		entry := atomEntry{
+			ID:      articleID(a),
+			Title:   a.Title,
+			Updated: modified(a).UTC().Format(time.RFC3339),
+		}
+		if t := published(a); !t.IsZero() {
+			entry.Published = t.UTC().Format(time.RFC3339)
+		}
+		if a.Author != "" {
+			entry.Author = &atomPerson{Name: a.Author}
+		}
+		if a.Link != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Type: "text/html", Href: a.Link}}
+		}
+		for _, category := range categories(a) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if a.Description != "" {
+			entry.Summary = &atomText{Type: "html", Body: a.Description}
+		}
+		if a.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: a.Content}
+		}
+		if a.FeedUrl != "" {
+			entry.Source = &atomSource{
+				ID:    a.FeedUrl,
+				Title: feedTitle(a),
+				Links: []atomLink{{Rel: "self", Href: a.FeedUrl}},
+			}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return writeXML(w, feed)
+}
+
+func writeXML(w io.Writer, v any) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,71 @@
+package planet
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	c "nned/internal/common"
+)
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	FeedUrl     string         `json:"feed_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	Url           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHtml   string           `json:"content_html"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+// writeJSONFeed writes a JSON Feed 1.1 document.
+func writeJSONFeed(w io.Writer, meta Meta, articles []c.Article) error {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       meta.Title,
+		Description: meta.Description,
+		FeedUrl:     meta.Link,
+		Items:       make([]jsonFeedItem, 0, len(articles)),
+	}
+	for _, a := range articles {
+		item := jsonFeedItem{
+			ID:          articleID(a),
+			Url:         a.Link,
+			Title:       a.Title,
+			ContentHtml: a.Description,
+			Tags:        categories(a),
+		}
+		if a.Content != "" {
+			item.ContentHtml = a.Content
+			item.Summary = a.Description
+		}
+		if t := published(a); !t.IsZero() {
+			item.DatePublished = t.Format(time.RFC3339)
+		}
+		if a.Updated != nil {
+			item.DateModified = a.Updated.Format(time.RFC3339)
+		}
+		if a.Author != "" {
+			item.Authors = []jsonFeedAuthor{{Name: a.Author}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(feed)
+}
@@ -0,0 +1,140 @@
+package planet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/store"
+)
+
+const (
+	FormatAtom     = "atom"
+	FormatRSS      = "rss"
+	FormatJSONFeed = "jsonfeed"
+)
+
+var Formats = []string{FormatAtom, FormatRSS, FormatJSONFeed}
+
+// Meta describes the merged feed. Link is the address the feed is published
+// at. RSS requires it; the other formats can do without.
+type Meta struct {
+	Title       string
+	Description string
+	Link        string
+}
+
+// Merge sorts the articles newest first and keeps a story covered by
+// several feeds once, as its newest article. story returns the key of the
+// article leading the story of the article stored under key; nil keeps every
+// article.
+func Merge(articles []c.Article, story func(key string) string) []c.Article {
+	sorted := slices.Clone(articles)
+	slices.SortStableFunc(sorted, func(a, b c.Article) int {
+		return published(b).Compare(published(a))
+	})
+	if story == nil {
+		return sorted
+	}
+	merged := make([]c.Article, 0, len(sorted))
+	seen := make(map[string]bool)
+	for _, a := range sorted {
+		s := story(store.Key(a))
+		if !seen[s] {
+			seen[s] = true
+			merged = append(merged, a)
+		}
+	}
+	return merged
+}
+
+func Write(w io.Writer, format string, meta Meta, articles []c.Article) error {
+	if meta.Title == "" {
+		meta.Title = "nned"
+	}
+	if meta.Description == "" {
+		meta.Description = "Articles collected by nned"
+	}
+	switch format {
+	case FormatAtom:
+		return writeAtom(w, meta, articles)
+	case FormatRSS:
+		if meta.Link == "" {
+			return errors.New("rss feeds need a link")
+		}
+		return writeRSS(w, meta, articles)
+	case FormatJSONFeed:
+		return writeJSONFeed(w, meta, articles)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FormatJSONFeed:
+		return "application/feed+json; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// articleID is a stable URI for an article, since links may change or be
+// shared by several articles.
+func articleID(a c.Article) string {
+	return "urn:nned:" + store.Key(a)
+}
+
+func published(a c.Article) time.Time {
+	if a.Date == nil {
+		return time.Time{}
+	}
+	return *a.Date
+}
+
+func modified(a c.Article) time.Time {
+	if a.Updated != nil {
+		return *a.Updated
+	}
+	return published(a)
+}
+
+// lastModified is the time of the latest change to any of the articles.
+func lastModified(articles []c.Article) time.Time {
+	var latest time.Time
+	for _, a := range articles {
+		if t := modified(a); t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return time.Now()
+	}
+	return latest
+}
+
+func feedTitle(a c.Article) string {
+	if a.SourceTitle != "" {
+		return a.SourceTitle
+	}
+	return a.Source
+}
+
+func categories(a c.Article) []string {
+	list := make([]string, 0, len(a.Tags)+1)
+	if a.Category != "" {
+		list = append(list, a.Category)
+	}
+	for _, tag := range a.Tags {
+		if !slices.Contains(list, tag) {
+			list = append(list, tag)
+		}
+	}
+	return list
+}
@@ -0,0 +1,165 @@
+package planet
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+)
+
+func at(day int) *time.Time {
+	t := time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC)
+	return &t
+}
+
+var articles = []c.Article{
+	{ID: "old", Title: "Old", Link: "https://a.example/old", Description: "old summary", Date: at(1), FeedUrl: "https://a.example/feed", SourceTitle: "A"},
+	{ID: "new", Title: "New", Link: "https://b.example/new", Description: "new summary", Content: "<p>full</p>", Date: at(3), Author: "Ann", Category: "Go", Tags: []string{"go", "Go"}},
+	{ID: "mid", Title: "Mid", Link: "https://a.example/mid", Date: at(2), Updated: at(4)},
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		story func(key string) string
+		want  []string
+	}{
+		{"sorted", nil, []string{"new", "mid", "old"}},
+		{"one story", func(string) string { return "s" }, []string{"new"}},
+		{"two stories", func(key string) string {
+			if key == "new" {
+				return "new"
+			}
+			return "old"
+		}, []string{"new", "mid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := Merge(articles, tt.story)
+			got := make([]string, len(merged))
+			for i, a := range merged {
+				got[i] = a.ID
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if articles[0].ID != "old" {
+		t.Error("Merge reordered its input")
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, FormatAtom, Meta{Link: "https://example.com/feed.xml"}, Merge(articles, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid atom: %v\n%s", err, buf.String())
+	}
+	if feed.Title != "nned" || feed.ID != "https://example.com/feed.xml" {
+		t.Errorf("feed = %q %q", feed.Title, feed.ID)
+	}
+	if feed.Updated != at(4).Format(time.RFC3339) {
+		t.Errorf("updated = %q, want the latest change", feed.Updated)
+	}
+	if len(feed.Entries) != 3 {
+		t.Fatalf("got %d entries", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "urn:nned:new" || entry.Content == nil || entry.Content.Body != "<p>full</p>" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if len(entry.Categories) != 2 {
+		t.Errorf("categories = %v, want Go and go", entry.Categories)
+	}
+}
+
+func TestWriteAtomWithoutLink(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, FormatAtom, Meta{Title: "Go news"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.ID != "urn:nned:feed:Go%20news" {
+		t.Errorf("id = %q", feed.ID)
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, FormatRSS, Meta{Title: "Go", Link: "https://example.com/rss.xml"}, Merge(articles, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc rssDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid rss: %v\n%s", err, buf.String())
+	}
+	ch := doc.Channel
+	if doc.Version != "2.0" || ch.Title != "Go" || ch.Description == "" {
+		t.Errorf("channel = %+v", ch)
+	}
+	// atom:link decodes into the same field, so look for the link itself.
+	if !strings.Contains(buf.String(), "<link>https://example.com/rss.xml</link>") {
+		t.Errorf("channel link missing:\n%s", buf.String())
+	}
+	if len(ch.Items) != 3 {
+		t.Fatalf("got %d items", len(ch.Items))
+	}
+	if item := ch.Items[0]; item.Description != "<p>full</p>" || item.PubDate != at(3).Format(time.RFC1123Z) {
+		t.Errorf("item = %+v", item)
+	}
+	if item := ch.Items[2]; item.Source == nil || item.Source.Url != "https://a.example/feed" || item.Source.Title != "A" {
+		t.Errorf("source = %+v", item.Source)
+	}
+}
+
+func TestWriteRSSNeedsLink(t *testing.T) {
+	err := Write(&bytes.Buffer{}, FormatRSS, Meta{}, articles)
+	if err == nil {
+		t.Error("wrote an RSS channel without a link")
+	}
+}
+
+func TestWriteJSONFeed(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, FormatJSONFeed, Meta{Link: "https://example.com/feed.json"}, Merge(articles, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed jsonFeed
+	if err := json.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid json feed: %v", err)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" || feed.FeedUrl != "https://example.com/feed.json" {
+		t.Errorf("feed = %+v", feed)
+	}
+	if len(feed.Items) != 3 {
+		t.Fatalf("got %d items", len(feed.Items))
+	}
+	first, second := feed.Items[0], feed.Items[1]
+	if first.ContentHtml != "<p>full</p>" || first.Summary != "new summary" || len(first.Authors) != 1 {
+		t.Errorf("item = %+v", first)
+	}
+	if second.DateModified != at(4).Format(time.RFC3339) {
+		t.Errorf("date_modified = %q", second.DateModified)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "csv", Meta{}, nil); err == nil {
+		t.Error("wrote an unknown format")
+	}
+}
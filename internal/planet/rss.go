@@ -0,0 +1,90 @@
+package planet
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	c "nned/internal/common"
+)
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      *atomLink `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssSource struct {
+	Url   string `xml:"url,attr"`
+	Title string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link,omitempty"`
+	GUID        rssGUID    `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+	Creator     string     `xml:"dc:creator,omitempty"`
+	Categories  []string   `xml:"category"`
+	Description string     `xml:"description,omitempty"`
+	Source      *rssSource `xml:"source"`
+}
+
+// writeRSS writes an RSS 2.0 document. Authors go into dc:creator, since
+// the RSS author element requires an email address.
+func writeRSS(w io.Writer, meta Meta, articles []c.Article) error {
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         meta.Title,
+			Link:          meta.Link,
+			Description:   meta.Description,
+			LastBuildDate: lastModified(articles).Format(time.RFC1123Z),
+			Generator:     "nned",
+			Items:         make([]rssItem, 0, len(articles)),
+		},
+	}
+	if meta.Link != "" {
+		doc.Channel.SelfLink = &atomLink{Rel: "self", Type: "application/rss+xml", Href: meta.Link}
+	}
+	for _, a := range articles {
+		item := rssItem{
+			Title:       a.Title,
+			Link:        a.Link,
+			GUID:        rssGUID{IsPermaLink: "false", Value: articleID(a)},
+			Creator:     a.Author,
+			Categories:  categories(a),
+			Description: a.Description,
+		}
+		if a.Content != "" {
+			item.Description = a.Content
+		}
+		if t := published(a); !t.IsZero() {
+			item.PubDate = t.Format(time.RFC1123Z)
+		}
+		if a.FeedUrl != "" {
+			item.Source = &rssSource{Url: a.FeedUrl, Title: feedTitle(a)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return writeXML(w, doc)
+}
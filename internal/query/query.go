@@ -0,0 +1,168 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	c "nned/internal/common"
+)
+
+// Query is a parsed search expression. Every term must match for an
+// article to match. Supported terms are free words, "quoted phrases",
+// feed:, cat:, tag:, is:read, is:unread, is:starred, after:, before: and their
+// negations with a leading "-".
+type Query struct {
+	raw   string
+	terms []term
+}
+
+// Target is what a query is matched against. Text is the plain text of the
+// article description, so that callers can cache the HTML conversion.
+type Target struct {
+	Article *c.Article
+	Text    string
+	Unread  bool
+	Starred bool
+}
+
+type term struct {
+	field  string
+	value  string
+	date   time.Time
+	negate bool
+}
+
+func Parse(s string) (Query, error) {
+	q := Query{raw: s}
+	for _, token := range tokenize(s) {
+		t := term{}
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			t.negate = true
+			token = token[1:]
+		}
+		field, value, ok := strings.Cut(token, ":")
+		if ok && isField(field) {
+			t.field = strings.ToLower(field)
+			t.value = strings.ToLower(unquote(value))
+		} else {
+			t.value = strings.ToLower(unquote(token))
+		}
+		switch t.field {
+		case "is":
+			if t.value != "read" && t.value != "unread" && t.value != "starred" {
+				return Query{}, fmt.Errorf("unknown state is:%s", t.value)
+			}
+		case "after", "before":
+			date, err := c.ParseDate(t.value)
+			if err != nil {
+				return Query{}, fmt.Errorf("invalid date %s:%s", t.field, t.value)
+			}
+			t.date = date
+		}
+		if t.value == "" {
+			continue
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+func (q Query) String() string {
+	return q.raw
+}
+
+// Starred reports whether the query depends on the starred state.
+func (q Query) Starred() bool {
+	for _, t := range q.terms {
+		if t.field == "is" && t.value == "starred" {
+			return true
+		}
+	}
+	return false
+}
+
+func (q Query) Empty() bool {
+	return len(q.terms) == 0
+}
+
+func (q Query) Match(target Target) bool {
+	for _, t := range q.terms {
+		if t.match(target) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t term) match(target Target) bool {
+	a := target.Article
+	switch t.field {
+	case "feed":
+		return contains(a.SourceTitle, t.value) || contains(a.Source, t.value)
+	case "cat":
+		return contains(a.Category, t.value)
+	case "tag":
+		for _, tag := range a.Tags {
+			if strings.EqualFold(tag, t.value) {
+				return true
+			}
+		}
+		return false
+	case "is":
+		if t.value == "starred" {
+			return target.Starred
+		}
+		return target.Unread == (t.value == "unread")
+	case "after":
+		return a.Date != nil && !a.Date.Before(t.date)
+	case "before":
+		return a.Date != nil && a.Date.Before(t.date)
+	}
+	return contains(a.Title, t.value) ||
+		contains(target.Text, t.value) ||
+		contains(a.SourceTitle, t.value) ||
+		contains(a.Category, t.value)
+}
+
+func isField(field string) bool {
+	switch strings.ToLower(field) {
+	case "feed", "cat", "tag", "is", "after", "before":
+		return true
+	}
+	return false
+}
+
+// tokenize splits on whitespace outside of double quotes.
+func tokenize(s string) []string {
+	tokens := make([]string, 0)
+	var sb strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			sb.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if sb.Len() > 0 {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"`)
+}
+
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), substr)
+}
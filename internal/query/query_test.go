@@ -0,0 +1,123 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		terms   int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"   ", 0, false},
+		{"go generics", 2, false},
+		{`"type parameters" go`, 2, false},
+		{"feed:lobsters -tag:jobs", 2, false},
+		{"is:unread is:starred -is:read", 3, false},
+		{"is:new", 0, true},
+		{"after:2024-01-01", 1, false},
+		{`before:"2024-01-01 12:00:00"`, 1, false},
+		{"after:yesterday", 0, true},
+		{"feed:", 0, false},
+		{"-", 1, false},
+		{"http://example.com", 1, false},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if len(q.terms) != tt.terms {
+			t.Errorf("Parse(%q) has %d terms, want %d", tt.input, len(q.terms), tt.terms)
+		}
+		if err == nil && q.String() != tt.input {
+			t.Errorf("String() = %q, want %q", q.String(), tt.input)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	article := &c.Article{
+		Title:       "Go generics in practice",
+		SourceTitle: "Lobsters",
+		Source:      "lobste.rs",
+		Category:    "Programming",
+		Tags:        []string{"Go", "plt"},
+		Date:        &date,
+	}
+	target := Target{Article: article, Text: "A look at type parameters", Unread: true}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"GENERICS", true},
+		{"generics rust", false},
+		{`"type parameters"`, true},
+		{`"parameters type"`, false},
+		{"programming", true},
+		{"feed:lobsters", true},
+		{"feed:lobste.rs", true},
+		{"feed:hn", false},
+		{"-feed:hn", true},
+		{"cat:program", true},
+		{"tag:go", true},
+		{"tag:g", false},
+		{"-tag:jobs", true},
+		{"is:unread", true},
+		{"is:read", false},
+		{"-is:read", true},
+		{"is:starred", false},
+		{"after:2024-03-01", true},
+		{"after:2024-03-02", false},
+		{"before:2024-03-02", true},
+		{`before:"2024-03-01 12:00:00"`, false},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		if got := q.Match(target); got != tt.want {
+			t.Errorf("%q matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchUndated(t *testing.T) {
+	for _, s := range []string{"after:2024-01-01", "before:2024-01-01"} {
+		q, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Match(Target{Article: &c.Article{}}) {
+			t.Errorf("%q matched an undated article", s)
+		}
+	}
+}
+
+func TestStarred(t *testing.T) {
+	tests := map[string]bool{
+		"is:starred":  true,
+		"-is:starred": true,
+		"is:unread":   false,
+		"starred":     false,
+	}
+	for s, want := range tests {
+		q, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Starred() != want {
+			t.Errorf("Parse(%q).Starred() = %v, want %v", s, q.Starred(), want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	c "nned/internal/common"
+)
+
+const (
+	ActionHide      = "hide"
+	ActionMarkRead  = "mark-read"
+	ActionHighlight = "highlight"
+	ActionTag       = "tag"
+	ActionStar      = "star"
+	ActionNotify    = "notify"
+)
+
+var Actions = []string{ActionHide, ActionMarkRead, ActionHighlight, ActionTag, ActionStar, ActionNotify}
+
+// Engine holds the compiled rules of a config, in config order.
+type Engine struct {
+	rules []*Rule
+}
+
+type Rule struct {
+	config      c.Rule
+	title       *regexp.Regexp
+	description *regexp.Regexp
+	author      *regexp.Regexp
+	olderThan   time.Duration
+	newerThan   time.Duration
+}
+
+// Result is the outcome of the rules for one article. Article carries the
+// highlight color and tags added by the rules; the flags are the actions
+// the caller has to carry out.
+type Result struct {
+	Article  c.Article
+	Matched  []string
+	Hide     bool
+	MarkRead bool
+	Star     bool
+	Notify   bool
+}
+
+func Compile(configs []c.Rule) (*Engine, error) {
+	e := &Engine{}
+	for i, config := range configs {
+		r, err := CompileRule(config)
+		if err != nil {
+			name := config.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("invalid rule %s: %w", name, err)
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+func CompileRule(config c.Rule) (*Rule, error) {
+	r := &Rule{config: config}
+	var err error
+	if r.title, err = compile(config.Title); err != nil {
+		return nil, fmt.Errorf("title: %w", err)
+	}
+	if r.description, err = compile(config.Description); err != nil {
+		return nil, fmt.Errorf("description: %w", err)
+	}
+	if r.author, err = compile(config.Author); err != nil {
+		return nil, fmt.Errorf("author: %w", err)
+	}
+	if config.OlderThan != "" {
+		if r.olderThan, err = time.ParseDuration(config.OlderThan); err != nil {
+			return nil, fmt.Errorf("older-than: %w", err)
+		}
+	}
+	if config.NewerThan != "" {
+		if r.newerThan, err = time.ParseDuration(config.NewerThan); err != nil {
+			return nil, fmt.Errorf("newer-than: %w", err)
+		}
+	}
+	if config.Feed == "" && config.Category == "" && r.title == nil && r.description == nil &&
+		r.author == nil && r.olderThan == 0 && r.newerThan == 0 {
+		return nil, errors.New("no conditions, it would match every article")
+	}
+
+	if len(config.Actions) == 0 {
+		return nil, errors.New("no actions")
+	}
+	for _, action := range config.Actions {
+		if !slices.Contains(Actions, action) {
+			return nil, fmt.Errorf("unknown action %q, expected one of %s", action, strings.Join(Actions, ", "))
+		}
+	}
+	if r.has(ActionHighlight) && config.Color == "" {
+		return nil, errors.New("highlight needs a color")
+	}
+	if r.has(ActionTag) && config.Tag == "" {
+		return nil, errors.New("tag needs a tag")
+	}
+	// Read and starred state are only set when an article is first seen,
+	// long before it is old enough to match.
+	if r.olderThan > 0 && (r.has(ActionMarkRead) || r.has(ActionStar)) {
+		return nil, errors.New("older-than cannot be combined with mark-read or star, which only act on articles as they arrive")
+	}
+	return r, nil
+}
+
+func compile(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+// Rule returns the compiled rule with the given name.
+func (e *Engine) Rule(name string) (*Rule, bool) {
+	for _, r := range e.rules {
+		if r.config.Name == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Apply runs every rule against the article. Actions of all matching rules
+// are combined; when several rules highlight, the first one wins.
+func (e *Engine) Apply(a c.Article) Result {
+	result := Result{Article: a}
+	if e == nil {
+		return result
+	}
+	for _, r := range e.rules {
+		r.apply(&result)
+	}
+	return result
+}
+
+// Apply runs a single rule against the article.
+func (r *Rule) Apply(a c.Article) Result {
+	result := Result{Article: a}
+	r.apply(&result)
+	return result
+}
+
+func (r *Rule) Name() string {
+	return r.config.Name
+}
+
+func (r *Rule) Actions() []string {
+	return r.config.Actions
+}
+
+func (r *Rule) apply(result *Result) {
+	if !r.Match(result.Article) {
+		return
+	}
+	result.Matched = append(result.Matched, r.config.Name)
+	for _, action := range r.config.Actions {
+		switch action {
+		case ActionHide:
+			result.Hide = true
+		case ActionMarkRead:
+			result.MarkRead = true
+		case ActionStar:
+			result.Star = true
+		case ActionNotify:
+			result.Notify = true
+		case ActionHighlight:
+			if result.Article.Highlight == "" {
+				result.Article.Highlight = r.config.Color
+			}
+		case ActionTag:
+			if !slices.Contains(result.Article.Tags, r.config.Tag) {
+				result.Article.Tags = append(slices.Clip(result.Article.Tags), r.config.Tag)
+			}
+		}
+	}
+}
+
+// Match reports whether every condition of the rule holds for the article.
+func (r *Rule) Match(a c.Article) bool {
+	if r.config.Feed != "" && !matchFeed(a, r.config.Feed) {
+		return false
+	}
+	if r.config.Category != "" && !strings.EqualFold(a.Category, r.config.Category) {
+		return false
+	}
+	if r.title != nil && !r.title.MatchString(a.Title) {
+		return false
+	}
+	if r.description != nil && !r.description.MatchString(a.Description) {
+		return false
+	}
+	if r.author != nil && !r.author.MatchString(a.Author) {
+		return false
+	}
+	if r.olderThan > 0 || r.newerThan > 0 {
+		if a.Date == nil {
+			return false
+		}
+		age := time.Since(*a.Date)
+		if r.olderThan > 0 && age < r.olderThan {
+			return false
+		}
+		if r.newerThan > 0 && age > r.newerThan {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Rule) has(action string) bool {
+	return slices.Contains(r.config.Actions, action)
+}
+
+func matchFeed(a c.Article, feed string) bool {
+	return strings.EqualFold(a.SourceTitle, feed) ||
+		strings.EqualFold(a.Source, feed) ||
+		a.FeedUrl == feed
+}
@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+)
+
+func TestCompileRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    c.Rule
+		wantErr string
+	}{
+		{"valid", c.Rule{Title: "go", Actions: []string{ActionHide}}, ""},
+		{"no conditions", c.Rule{Actions: []string{ActionHide}}, "no conditions"},
+		{"no actions", c.Rule{Feed: "x"}, "no actions"},
+		{"unknown action", c.Rule{Feed: "x", Actions: []string{"delete"}}, "unknown action"},
+		{"bad regexp", c.Rule{Title: "(", Actions: []string{ActionHide}}, "title"},
+		{"bad duration", c.Rule{OlderThan: "soon", Actions: []string{ActionHide}}, "older-than"},
+		{"highlight without color", c.Rule{Feed: "x", Actions: []string{ActionHighlight}}, "color"},
+		{"tag without tag", c.Rule{Feed: "x", Actions: []string{ActionTag}}, "tag needs"},
+		{"older-than with mark-read", c.Rule{OlderThan: "24h", Actions: []string{ActionMarkRead}}, "older-than cannot"},
+		{"older-than with star", c.Rule{Feed: "x", OlderThan: "24h", Actions: []string{ActionHighlight, ActionStar}, Color: "red"}, "older-than cannot"},
+		{"older-than with hide", c.Rule{OlderThan: "24h", Actions: []string{ActionHide}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileRule(tt.rule)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileNamesRule(t *testing.T) {
+	_, err := Compile([]c.Rule{
+		{Name: "ok", Feed: "x", Actions: []string{ActionHide}},
+		{Feed: "x"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "#2") {
+		t.Fatalf("error = %v, want one naming rule #2", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	day := time.Now().Add(-24 * time.Hour)
+	article := c.Article{
+		Title:       "Go 1.24 released",
+		Description: "Generic type aliases",
+		Author:      "The Go Team",
+		Source:      "go.dev",
+		SourceTitle: "Go Blog",
+		FeedUrl:     "https://go.dev/blog/feed.atom",
+		Category:    "Programming",
+		Date:        &day,
+	}
+	tests := []struct {
+		name string
+		rule c.Rule
+		want bool
+	}{
+		{"feed title", c.Rule{Feed: "go blog"}, true},
+		{"feed url", c.Rule{Feed: "https://go.dev/blog/feed.atom"}, true},
+		{"other feed", c.Rule{Feed: "lobsters"}, false},
+		{"category", c.Rule{Category: "programming"}, true},
+		{"title", c.Rule{Title: `1\.2\d`}, true},
+		{"description", c.Rule{Description: "aliases$"}, true},
+		{"author", c.Rule{Author: "^Rob"}, false},
+		{"older than", c.Rule{OlderThan: "1h"}, true},
+		{"not older than", c.Rule{OlderThan: "48h"}, false},
+		{"newer than", c.Rule{NewerThan: "48h"}, true},
+		{"all conditions", c.Rule{Feed: "go.dev", Title: "Go", Author: "Team"}, true},
+		{"one condition fails", c.Rule{Feed: "go.dev", Title: "Rust"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Actions = []string{ActionHide}
+			r, err := CompileRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Match(article); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchUndated(t *testing.T) {
+	r, err := CompileRule(c.Rule{OlderThan: "1h", Actions: []string{ActionHide}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Match(c.Article{Title: "x"}) {
+		t.Error("an age condition matched an undated article")
+	}
+}
+
+func TestApply(t *testing.T) {
+	e, err := Compile([]c.Rule{
+		{Name: "sponsored", Title: "(?i)sponsored", Actions: []string{ActionHide, ActionMarkRead}},
+		{Name: "go", Title: "Go", Actions: []string{ActionHighlight, ActionTag}, Color: "red", Tag: "golang"},
+		{Name: "go again", Title: "Go", Actions: []string{ActionHighlight, ActionTag, ActionStar, ActionNotify}, Color: "blue", Tag: "golang"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := e.Apply(c.Article{Title: "Go generics", Tags: []string{"lang"}})
+	if !slices.Equal(result.Matched, []string{"go", "go again"}) {
+		t.Errorf("Matched = %v", result.Matched)
+	}
+	if result.Hide || result.MarkRead || !result.Star || !result.Notify {
+		t.Errorf("flags = %+v", result)
+	}
+	if result.Article.Highlight != "red" {
+		t.Errorf("Highlight = %q, want the first rule's red", result.Article.Highlight)
+	}
+	if !slices.Equal(result.Article.Tags, []string{"lang", "golang"}) {
+		t.Errorf("Tags = %v, want the tag added once", result.Article.Tags)
+	}
+
+	result = e.Apply(c.Article{Title: "Sponsored: buy now"})
+	if !result.Hide || !result.MarkRead || result.Star {
+		t.Errorf("flags = %+v", result)
+	}
+
+	var none *Engine
+	if result := none.Apply(c.Article{Title: "Go"}); len(result.Matched) != 0 {
+		t.Errorf("nil engine matched %v", result.Matched)
+	}
+}
+
+func TestApplyKeepsTags(t *testing.T) {
+	tags := make([]string, 1, 4)
+	tags[0] = "lang"
+	e, err := Compile([]c.Rule{{Title: "x", Actions: []string{ActionTag}, Tag: "t"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Apply(c.Article{Title: "x", Tags: tags})
+	if got := tags[:2][1]; got != "" {
+		t.Errorf("Apply wrote %q into the article's tag array", got)
+	}
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/query"
+	"nned/internal/store"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Article struct {
+	ID          string     `json:"id"`
+	Story       string     `json:"story,omitempty"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Author      string     `json:"author,omitempty"`
+	Date        *time.Time `json:"date"`
+	Updated     *time.Time `json:"updated,omitempty"`
+	Feed        string     `json:"feed"`
+	FeedUrl     string     `json:"feed_url,omitempty"`
+	Category    string     `json:"category,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	Read        bool       `json:"read"`
+	Starred     bool       `json:"starred"`
+}
+
+type articleList struct {
+	Total    int       `json:"total"`
+	Offset   int       `json:"offset"`
+	Limit    int       `json:"limit"`
+	Articles []Article `json:"articles"`
+}
+
+type Feed struct {
+	Url                 string     `json:"url"`
+	Title               string     `json:"title"`
+	Category            string     `json:"category,omitempty"`
+	Health              string     `json:"health"`
+	LastAttempt         *time.Time `json:"last_attempt,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastStatus          int        `json:"last_status,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Items               int        `json:"items"`
+	NextFetch           *time.Time `json:"next_fetch,omitempty"`
+}
+
+type articleUpdate struct {
+	Read    *bool `json:"read"`
+	Starred *bool `json:"starred"`
+}
+
+// listArticles returns the articles newest first. They can be narrowed
+// with feed, category, unread, starred, since (a duration) and q (the search
+// syntax of the UI), and paged with offset and limit.
+func (s *Server) listArticles(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q, err := query.Parse(params.Get("q"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	offset, err := intParam(params.Get("offset"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, err := intParam(params.Get("limit"), defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit = min(max(limit, 1), maxLimit)
+	var since time.Time
+	if v := params.Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since: %w", err))
+			return
+		}
+		since = time.Now().Add(-d)
+	}
+	unread, err := boolParam(params, "unread")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	starred, err := boolParam(params, "starred")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	feed, category := params.Get("feed"), params.Get("category")
+	matched := make([]c.Article, 0)
+	for _, a := range s.monitor.StoredArticles() {
+		if feed != "" && !strings.EqualFold(feedName(a), feed) && a.FeedUrl != feed {
+			continue
+		}
+		if category != "" && !strings.EqualFold(a.Category, category) {
+			continue
+		}
+		r, _ := s.store.Get(store.Key(a))
+		if unread != nil && *unread == r.Read {
+			continue
+		}
+		if starred != nil && *starred != r.Starred {
+			continue
+		}
+		if !since.IsZero() && (a.Date == nil || a.Date.Before(since)) {
+			continue
+		}
+		if !q.Empty() && !q.Match(query.Target{Article: &a, Text: a.Description, Unread: !r.Read, Starred: r.Starred}) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+
+	// Only the requested page is converted, which looks up the story of
+	// each article.
+	list := articleList{Total: len(matched), Offset: offset, Limit: limit, Articles: []Article{}}
+	if offset < len(matched) {
+		for _, a := range matched[offset:min(offset+limit, len(matched))] {
+			list.Articles = append(list.Articles, s.convert(a))
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getArticle(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.find(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+// updateArticle sets the read and starred state given in the body.
+func (s *Server) updateArticle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.store.Get(id); !ok {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	var update articleUpdate
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if update.Read != nil {
+		err = s.store.SetRead(id, *update.Read)
+	}
+	if err == nil && update.Starred != nil {
+		err = s.store.SetStarred(id, *update.Starred)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a, _ := s.find(id)
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (s *Server) listFeeds(w http.ResponseWriter, _ *http.Request) {
+	statuses := s.monitor.FeedStatus()
+	feeds := make([]Feed, 0, len(statuses))
+	for _, status := range statuses {
+		feed := Feed{
+			Url:                 status.Feed.Url,
+			Title:               status.Feed.Title,
+			Category:            status.Feed.Category,
+			Health:              status.Health().String(),
+			LastAttempt:         optionalTime(status.LastAttempt),
+			LastSuccess:         optionalTime(status.LastSuccess),
+			LastStatus:          status.LastStatus,
+			ConsecutiveFailures: status.ConsecutiveFailures,
+			Items:               status.ItemCount,
+			NextFetch:           optionalTime(status.NextFetch),
+		}
+		if status.LastError != nil {
+			feed.LastError = status.LastError.Error()
+		}
+		feeds = append(feeds, feed)
+	}
+	writeJSON(w, http.StatusOK, feeds)
+}
+
+func (s *Server) refresh(w http.ResponseWriter, _ *http.Request) {
+	s.monitor.Refresh()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// events streams new articles as Server-Sent Events until the client goes
+// away. A comment is sent regularly so that proxies keep the connection.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case article := <-ch:
+			data, err := json.Marshal(s.convert(article))
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: article\nid: %s\ndata: %s\n\n", store.Key(article), data)
+		}
+		flusher.Flush()
+	}
+}
+
+// find returns the article as the monitor shows it, or the stored record
+// when the monitor leaves it out, such as a starred article of a feed that
+// was removed.
+func (s *Server) find(id string) (Article, bool) {
+	if a, ok := s.monitor.StoredArticle(id); ok {
+		return s.convert(a), true
+	}
+	r, ok := s.store.Get(id)
+	if !ok {
+		return Article{}, false
+	}
+	a := r.Article
+	a.Starred = r.Starred
+	return s.convert(a), true
+}
+
+func (s *Server) convert(a c.Article) Article {
+	key := store.Key(a)
+	r, _ := s.store.Get(key)
+	story := ""
+	if s.monitor.Clustering() {
+		story = s.monitor.Story(key)
+	}
+	return Article{
+		ID:          key,
+		Story:       story,
+		Title:       a.Title,
+		Link:        a.Link,
+		Author:      a.Author,
+		Date:        a.Date,
+		Updated:     a.Updated,
+		Feed:        feedName(a),
+		FeedUrl:     a.FeedUrl,
+		Category:    a.Category,
+		Tags:        a.Tags,
+		Description: a.Description,
+		Content:     a.Content,
+		Read:        r.Read,
+		Starred:     r.Starred,
+	}
+}
+
+func feedName(a c.Article) string {
+	if a.SourceTitle != "" {
+		return a.SourceTitle
+	}
+	return a.Source
+}
+
+func intParam(v string, fallback int) (int, error) {
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", v)
+	}
+	return n, nil
+}
+
+func boolParam(params map[string][]string, name string) (*bool, error) {
+	values, ok := params[name]
+	if !ok || len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %q", name, values[0])
+	}
+	return &b, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	errUnauthorized = errors.New("missing or invalid token")
+	errLocalOnly    = errors.New("the api only answers local requests until a sync api-key is configured")
+	errHost         = errors.New("the api only answers requests for localhost until a sync api-key is configured")
+)
+
+// authorize guards the /api endpoints. Once sync credentials are configured
+// the server is meant to be reached from other devices, so every request has
+// to carry the api key, as a bearer token or, for feed readers and
+// EventSource clients that cannot set headers, as the token parameter.
+// Without an api key only requests from the local host are answered, and
+// only when they name a loopback host: a page in a local browser could
+// otherwise reach the api through a DNS name rebound to 127.0.0.1.
+func (s *Server) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		apiKey := s.config.Sync.APIKey
+		s.mu.RUnlock()
+		if apiKey == "" {
+			if !isLoopback(r.RemoteAddr) {
+				writeError(w, http.StatusForbidden, errLocalOnly)
+				return
+			}
+			if !isLocalHost(r.Host) {
+				writeError(w, http.StatusForbidden, errHost)
+				return
+			}
+			next(w, r)
+			return
+		}
+		token := r.URL.Query().Get("token")
+		if header, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = header
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="nned"`)
+			writeError(w, http.StatusUnauthorized, errUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLocalHost reports whether the Host header of a request names the local
+// host itself rather than a DNS name.
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	c "nned/internal/common"
+	"nned/internal/planet"
+)
+
+// feed serves the shown articles as one merged feed, optionally of a
+// single category. The format defaults to Atom.
+func (s *Server) feed(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = planet.FormatAtom
+	}
+	if !slices.Contains(planet.Formats, format) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(planet.Formats, ", ")))
+		return
+	}
+	limit, err := intParam(params.Get("limit"), defaultLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit = min(max(limit, 1), maxLimit)
+
+	category := params.Get("category")
+	articles := make([]c.Article, 0)
+	for _, a := range s.monitor.StoredArticles() {
+		if category == "" || strings.EqualFold(a.Category, category) {
+			articles = append(articles, a)
+		}
+	}
+	articles = planet.Merge(articles, s.monitor.Stories())
+	articles = articles[:min(limit, len(articles))]
+
+	meta := planet.Meta{
+		Title: params.Get("title"),
+		Link:  s.feedURL(r),
+	}
+	if meta.Title == "" && category != "" {
+		meta.Title = "nned: " + category
+	}
+	w.Header().Set("Content-Type", planet.ContentType(format))
+	err = planet.Write(w, format, meta, articles)
+	if err != nil {
+		s.reportError(fmt.Errorf("unable to write feed: %w", err))
+	}
+}
+
+// feedURL is the address of the requested feed under the configured base
+// URL. The Host header is not trusted for it, and the token is left out so
+// that the api key does not end up in the feed.
+func (s *Server) feedURL(r *http.Request) string {
+	s.mu.RLock()
+	base := strings.TrimSuffix(s.config.Sync.BaseURL, "/")
+	s.mu.RUnlock()
+	params := r.URL.Query()
+	params.Del("token")
+	link := base + r.URL.Path
+	if len(params) > 0 {
+		link += "?" + params.Encode()
+	}
+	return link
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"cmp"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	c "nned/internal/common"
+	mon "nned/internal/monitor"
+	"nned/internal/store"
+)
+
+const (
+	feverVersion  = 3
+	feverMaxItems = 50
+)
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	Url               string `json:"url"`
+	SiteUrl           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	Html          string `json:"html"`
+	Url           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverEntry is a stored article as the Fever API sees it.
+type feverEntry struct {
+	key     string
+	record  store.Record
+	article c.Article
+}
+
+// fever implements the subset of the Fever API that mobile clients use to
+// read articles and sync their read and saved state. It answers with 404
+// while no credentials are configured. Every request carries
+// api_key, the md5 of "username:api-key", and selects what to return with
+// query parameters, so several can be combined in one request.
+func (s *Server) fever(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	sync := s.config.Sync
+	s.mu.RUnlock()
+	if sync.Username == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if _, ok := r.URL.Query()["api"]; !ok {
+		http.Error(w, "missing api parameter", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]any{"api_version": feverVersion, "auth": 0}
+	if !feverAuthorized(sync, r.FormValue("api_key")) {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	response["auth"] = 1
+
+	feeds := s.monitor.FeedStatus()
+	var refreshed time.Time
+	for _, status := range feeds {
+		if status.LastSuccess.After(refreshed) {
+			refreshed = status.LastSuccess
+		}
+	}
+	response["last_refreshed_on_time"] = unix(refreshed)
+
+	params := r.URL.Query()
+	if r.FormValue("mark") != "" {
+		err := s.feverMark(r.FormValue("mark"), r.FormValue("as"), r.FormValue("id"), r.FormValue("before"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		switch r.FormValue("as") {
+		case "read", "unread":
+			params.Set("unread_item_ids", "")
+		case "saved", "unsaved":
+			params.Set("saved_item_ids", "")
+		}
+	}
+
+	entries := s.feverEntries()
+	if params.Has("groups") || params.Has("feeds") {
+		response["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+	if params.Has("groups") {
+		groups := make([]feverGroup, 0)
+		for _, category := range categories(feeds) {
+			groups = append(groups, feverGroup{ID: groupID(category), Title: category})
+		}
+		response["groups"] = groups
+	}
+	if params.Has("feeds") {
+		list := make([]feverFeed, 0, len(feeds))
+		for _, status := range feeds {
+			title := status.Feed.Title
+			if title == "" {
+				title = status.Feed.Url
+			}
+			list = append(list, feverFeed{
+				ID:                feedID(status.Feed.Url),
+				Title:             title,
+				Url:               status.Feed.Url,
+				SiteUrl:           status.Feed.Url,
+				LastUpdatedOnTime: unix(status.LastSuccess),
+			})
+		}
+		response["feeds"] = list
+	}
+	if params.Has("favicons") {
+		response["favicons"] = []any{}
+	}
+	if params.Has("links") {
+		response["links"] = []any{}
+	}
+	if params.Has("items") {
+		response["items"] = feverItems(entries, params)
+		response["total_items"] = len(entries)
+	}
+	if params.Has("unread_item_ids") {
+		response["unread_item_ids"] = itemIDs(entries, func(e feverEntry) bool { return !e.record.Read })
+	}
+	if params.Has("saved_item_ids") {
+		response["saved_item_ids"] = itemIDs(entries, func(e feverEntry) bool { return e.record.Starred })
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func feverAuthorized(sync c.Sync, apiKey string) bool {
+	sum := md5.Sum([]byte(sync.Username + ":" + sync.APIKey))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(apiKey))) == 1
+}
+
+// feverEntries returns the articles the server shows together with the saved
+// ones that are no longer shown, ordered by sequence number.
+func (s *Server) feverEntries() []feverEntry {
+	entries := make([]feverEntry, 0)
+	seen := make(map[string]bool)
+	for _, a := range s.monitor.StoredArticles() {
+		key := store.Key(a)
+		r, ok := s.store.Get(key)
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		entries = append(entries, feverEntry{key: key, record: r, article: a})
+	}
+	for _, r := range s.store.Records() {
+		key := store.Key(r.Article)
+		if r.Starred && !seen[key] {
+			entries = append(entries, feverEntry{key: key, record: r, article: r.Article})
+		}
+	}
+	slices.SortFunc(entries, func(a, b feverEntry) int {
+		return cmp.Compare(a.record.Seq, b.record.Seq)
+	})
+	return entries
+}
+
+// feverItems pages through the items: with_ids selects them directly,
+// max_id returns the ones before it newest first and since_id the ones after
+// it oldest first.
+func feverItems(entries []feverEntry, params url.Values) []feverItem {
+	items := make([]feverItem, 0)
+	add := func(e feverEntry) bool {
+		items = append(items, toFeverItem(e))
+		return len(items) < feverMaxItems
+	}
+	if ids := params.Get("with_ids"); ids != "" {
+		wanted := make(map[int64]bool)
+		for _, id := range strings.Split(ids, ",") {
+			n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err == nil {
+				wanted[n] = true
+			}
+		}
+		for _, e := range entries {
+			if wanted[e.record.Seq] && !add(e) {
+				break
+			}
+		}
+		return items
+	}
+	if maxID, err := strconv.ParseInt(params.Get("max_id"), 10, 64); err == nil && maxID > 0 {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].record.Seq < maxID && !add(entries[i]) {
+				break
+			}
+		}
+		return items
+	}
+	sinceID, _ := strconv.ParseInt(params.Get("since_id"), 10, 64)
+	for _, e := range entries {
+		if e.record.Seq > sinceID && !add(e) {
+			break
+		}
+	}
+	return items
+}
+
+func toFeverItem(e feverEntry) feverItem {
+	html := e.article.Content
+	if html == "" {
+		html = e.article.Description
+	}
+	created := e.record.FirstSeen
+	if e.article.Date != nil {
+		created = *e.article.Date
+	}
+	return feverItem{
+		ID:            e.record.Seq,
+		FeedID:        feedID(e.article.FeedUrl),
+		Title:         e.article.Title,
+		Author:        e.article.Author,
+		Html:          html,
+		Url:           e.article.Link,
+		IsSaved:       flag(e.record.Starred),
+		IsRead:        flag(e.record.Read),
+		CreatedOnTime: created.Unix(),
+	}
+}
+
+// feverMark changes the state of one item, or marks the items of a feed or
+// group read up to the before timestamp. Group 0 stands for every feed.
+func (s *Server) feverMark(mark, as, id, before string) error {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return errInvalid("id", id)
+	}
+	if mark == "item" {
+		key, ok := s.store.KeyOf(n)
+		if !ok {
+			return errNotFound
+		}
+		switch as {
+		case "read", "unread":
+			return s.store.SetRead(key, as == "read")
+		case "saved", "unsaved":
+			return s.store.SetStarred(key, as == "saved")
+		}
+		return errInvalid("as", as)
+	}
+	if as != "read" {
+		return errInvalid("as", as)
+	}
+	cutoff := time.Now()
+	if before != "" {
+		ts, err := strconv.ParseInt(before, 10, 64)
+		if err != nil {
+			return errInvalid("before", before)
+		}
+		cutoff = time.Unix(ts, 0)
+	}
+	var matches func(a c.Article) bool
+	switch mark {
+	case "feed":
+		matches = func(a c.Article) bool { return feedID(a.FeedUrl) == n }
+	case "group":
+		matches = func(a c.Article) bool { return n == 0 || a.Category != "" && groupID(a.Category) == n }
+	default:
+		return errInvalid("mark", mark)
+	}
+	keys := make([]string, 0)
+	for _, e := range s.feverEntries() {
+		created := toFeverItem(e).CreatedOnTime
+		if !e.record.Read && matches(e.article) && created <= cutoff.Unix() {
+			keys = append(keys, e.key)
+		}
+	}
+	return s.store.SetReadMany(keys, true)
+}
+
+func feverFeedsGroups(feeds []mon.FeedStatus) []feverFeedsGroup {
+	byGroup := make(map[string][]string)
+	for _, status := range feeds {
+		if status.Feed.Category != "" {
+			byGroup[status.Feed.Category] = append(byGroup[status.Feed.Category], strconv.FormatInt(feedID(status.Feed.Url), 10))
+		}
+	}
+	groups := make([]feverFeedsGroup, 0, len(byGroup))
+	for _, category := range categories(feeds) {
+		groups = append(groups, feverFeedsGroup{GroupID: groupID(category), FeedIDs: strings.Join(byGroup[category], ",")})
+	}
+	return groups
+}
+
+func categories(feeds []mon.FeedStatus) []string {
+	list := make([]string, 0)
+	for _, status := range feeds {
+		if status.Feed.Category != "" && !slices.Contains(list, status.Feed.Category) {
+			list = append(list, status.Feed.Category)
+		}
+	}
+	return list
+}
+
+func itemIDs(entries []feverEntry, include func(feverEntry) bool) string {
+	ids := make([]string, 0)
+	for _, e := range entries {
+		if include(e) {
+			ids = append(ids, strconv.FormatInt(e.record.Seq, 10))
+		}
+	}
+	return strings.Join(ids, ",")
+}
+
+// feedID and groupID derive stable numeric identifiers from the feed URL
+// and the category, since Fever has no room for strings.
+func feedID(url string) int64 {
+	return hash31("feed:" + url)
+}
+
+func groupID(category string) int64 {
+	return hash31("group:" + category)
+}
+
+func hash31(s string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return int64(h.Sum32()&0x7fffffff) + 1
+}
+
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
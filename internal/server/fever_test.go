@@ -0,0 +1,207 @@
+package server
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+)
+
+var feverConfig = c.Config{Sync: c.Sync{Username: "alice", APIKey: "secret"}}
+
+type feverResponse struct {
+	APIVersion    int               `json:"api_version"`
+	Auth          int               `json:"auth"`
+	Items         []feverItem       `json:"items"`
+	TotalItems    int               `json:"total_items"`
+	Groups        []feverGroup      `json:"groups"`
+	Feeds         []feverFeed       `json:"feeds"`
+	FeedsGroups   []feverFeedsGroup `json:"feeds_groups"`
+	UnreadItemIDs *string           `json:"unread_item_ids"`
+	SavedItemIDs  *string           `json:"saved_item_ids"`
+}
+
+func feverKey(username, apiKey string) string {
+	sum := md5.Sum([]byte(username + ":" + apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// feverPost sends a Fever request the way clients do: the query selects
+// what to return and the form carries the key and the changes.
+func feverPost(s *Server, query string, form url.Values) *httptest.ResponseRecorder {
+	if form == nil {
+		form = url.Values{}
+	}
+	if !form.Has("api_key") {
+		form.Set("api_key", feverKey("alice", "secret"))
+	}
+	r := httptest.NewRequest("POST", "/fever/?api&"+query, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func itemIDList(items []feverItem) string {
+	list := make([]string, len(items))
+	for i, item := range items {
+		list[i] = strconv.FormatInt(item.ID, 10)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestFeverAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		config c.Config
+		key    string
+		status int
+		auth   int
+	}{
+		{"disabled", c.Config{}, feverKey("alice", "secret"), http.StatusNotFound, 0},
+		{"valid", feverConfig, feverKey("alice", "secret"), http.StatusOK, 1},
+		{"upper case", feverConfig, strings.ToUpper(feverKey("alice", "secret")), http.StatusOK, 1},
+		{"wrong key", feverConfig, feverKey("alice", "guess"), http.StatusOK, 0},
+		{"wrong user", feverConfig, feverKey("bob", "secret"), http.StatusOK, 0},
+		{"plain key", feverConfig, "secret", http.StatusOK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t, tt.config)
+			w := feverPost(s, "items", url.Values{"api_key": {tt.key}})
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d", w.Code, tt.status)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			resp := decode[feverResponse](t, w)
+			if resp.APIVersion != feverVersion || resp.Auth != tt.auth {
+				t.Errorf("response = %+v", resp)
+			}
+			if tt.auth == 0 && resp.Items != nil {
+				t.Error("items returned without authentication")
+			}
+		})
+	}
+}
+
+func TestFeverMissingAPI(t *testing.T) {
+	s, _ := newTestServer(t, feverConfig)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/fever/", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", w.Code)
+	}
+}
+
+func TestFeverItems(t *testing.T) {
+	s, _ := newTestServer(t, feverConfig)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"items", "1,2,3,4"},
+		{"items&since_id=2", "3,4"},
+		{"items&max_id=3", "2,1"},
+		{"items&with_ids=4,1,x,9", "1,4"},
+	}
+	for _, tt := range tests {
+		resp := decode[feverResponse](t, feverPost(s, tt.query, nil))
+		if got := itemIDList(resp.Items); got != tt.want || resp.TotalItems != 4 {
+			t.Errorf("%s: items %q of %d, want %q of 4", tt.query, got, resp.TotalItems, tt.want)
+		}
+	}
+
+	resp := decode[feverResponse](t, feverPost(s, "items&with_ids=1,2", nil))
+	first, second := resp.Items[0], resp.Items[1]
+	if first.Title != "Go 1.24" || first.Html != "Generic type aliases" || first.Url != "https://go.dev/1" || first.IsRead != 0 {
+		t.Errorf("item = %+v", first)
+	}
+	if first.FeedID != feedID(goFeed) || second.IsRead != 1 {
+		t.Errorf("items = %+v", resp.Items)
+	}
+}
+
+func TestFeverFeedsAndGroups(t *testing.T) {
+	s, _ := newTestServer(t, feverConfig)
+	resp := decode[feverResponse](t, feverPost(s, "groups&feeds", nil))
+	if len(resp.Groups) != 1 || resp.Groups[0].Title != "Go" || resp.Groups[0].ID != groupID("Go") {
+		t.Errorf("groups = %+v", resp.Groups)
+	}
+	if len(resp.Feeds) != 2 || resp.Feeds[0].ID != feedID(goFeed) || resp.Feeds[1].Title != "Rust" {
+		t.Errorf("feeds = %+v", resp.Feeds)
+	}
+	want := []feverFeedsGroup{{GroupID: groupID("Go"), FeedIDs: strconv.FormatInt(feedID(goFeed), 10)}}
+	if len(resp.FeedsGroups) != 1 || resp.FeedsGroups[0] != want[0] {
+		t.Errorf("feeds_groups = %+v, want %+v", resp.FeedsGroups, want)
+	}
+}
+
+func TestFeverMark(t *testing.T) {
+	tests := []struct {
+		name   string
+		form   url.Values
+		unread string
+		saved  string
+	}{
+		{"item read", url.Values{"mark": {"item"}, "as": {"read"}, "id": {"1"}}, "3,4", ""},
+		{"item unread", url.Values{"mark": {"item"}, "as": {"unread"}, "id": {"2"}}, "1,2,3,4", ""},
+		{"item saved", url.Values{"mark": {"item"}, "as": {"saved"}, "id": {"1"}}, "", "1,3,4"},
+		{"item unsaved", url.Values{"mark": {"item"}, "as": {"unsaved"}, "id": {"4"}}, "", "3"},
+		{"feed read", url.Values{"mark": {"feed"}, "as": {"read"}, "id": {strconv.FormatInt(feedID(goFeed), 10)}}, "3,4", ""},
+		{"group read", url.Values{"mark": {"group"}, "as": {"read"}, "id": {strconv.FormatInt(groupID("Go"), 10)}}, "3,4", ""},
+		{"everything read", url.Values{"mark": {"group"}, "as": {"read"}, "id": {"0"}}, "", ""},
+		{"read before", url.Values{"mark": {"group"}, "as": {"read"}, "id": {"0"}, "before": {strconv.FormatInt(ago(150*time.Minute).Unix(), 10)}}, "1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t, feverConfig)
+			w := feverPost(s, "", tt.form)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			resp := decode[feverResponse](t, w)
+			switch tt.form.Get("as") {
+			case "read", "unread":
+				if resp.UnreadItemIDs == nil || *resp.UnreadItemIDs != tt.unread {
+					t.Errorf("unread_item_ids = %v, want %q", deref(resp.UnreadItemIDs), tt.unread)
+				}
+			default:
+				if resp.SavedItemIDs == nil || *resp.SavedItemIDs != tt.saved {
+					t.Errorf("saved_item_ids = %v, want %q", deref(resp.SavedItemIDs), tt.saved)
+				}
+			}
+		})
+	}
+}
+
+func TestFeverMarkInvalid(t *testing.T) {
+	s, _ := newTestServer(t, feverConfig)
+	for _, form := range []url.Values{
+		{"mark": {"item"}, "as": {"read"}, "id": {"99"}},
+		{"mark": {"item"}, "as": {"read"}, "id": {"one"}},
+		{"mark": {"item"}, "as": {"starred"}, "id": {"1"}},
+		{"mark": {"feed"}, "as": {"unread"}, "id": {"1"}},
+		{"mark": {"group"}, "as": {"read"}, "id": {"0"}, "before": {"yesterday"}},
+		{"mark": {"folder"}, "as": {"read"}, "id": {"1"}},
+	} {
+		if w := feverPost(s, "", form); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: status %d, want 400", form, w.Code)
+		}
+	}
+}
+
+func deref(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	c "nned/internal/common"
+	mon "nned/internal/monitor"
+	"nned/internal/store"
+)
+
+const (
+	subscriberBuffer = 64
+	publishedTTL     = 24 * time.Hour
+)
+
+// Server exposes the articles of a running monitor and their read and
+// starred state over HTTP.
+type Server struct {
+	monitor     *mon.Monitor
+	store       *store.Store
+	mux         *http.ServeMux
+	mu          sync.RWMutex
+	subscribers map[chan c.Article]struct{}
+	published   map[string]publication
+	swept       time.Time
+	config      c.Config
+	onError     func(err error)
+}
+
+// publication is when an article was last sent to the event stream, as of
+// which update, and when the monitor last reported it.
+type publication struct {
+	updated time.Time
+	seen    time.Time
+}
+
+func New(monitor *mon.Monitor, st *store.Store) *Server {
+	s := &Server{
+		monitor:     monitor,
+		store:       st,
+		mux:         http.NewServeMux(),
+		subscribers: make(map[chan c.Article]struct{}),
+		published:   make(map[string]publication),
+		swept:       time.Now(),
+	}
+	for _, r := range st.Records() {
+		s.published[store.Key(r.Article)] = publication{updated: updatedAt(r.Article), seen: s.swept}
+	}
+	s.mux.HandleFunc("GET /api/articles", s.authorize(s.listArticles))
+	s.mux.HandleFunc("GET /api/articles/{id}", s.authorize(s.getArticle))
+	s.mux.HandleFunc("PATCH /api/articles/{id}", s.authorize(s.updateArticle))
+	s.mux.HandleFunc("GET /api/feeds", s.authorize(s.listFeeds))
+	s.mux.HandleFunc("POST /api/refresh", s.authorize(s.refresh))
+	s.mux.HandleFunc("GET /api/events", s.authorize(s.events))
+	s.mux.HandleFunc("GET /api/feed", s.authorize(s.feed))
+	s.mux.HandleFunc("/fever", s.fever)
+	s.mux.HandleFunc("/fever/", s.fever)
+	return s
+}
+
+// Configure sets the parts of the config the server uses besides the
+// monitor, the sync credentials that guard the Fever API and /api and the
+// base URL of the served feeds.
+func (s *Server) Configure(config c.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+// SetOnError sets where errors that cannot be sent to a client, such as a
+// response failing half way, are reported.
+func (s *Server) SetOnError(onError func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = onError
+}
+
+func (s *Server) reportError(err error) {
+	s.mu.RLock()
+	onError := s.onError
+	s.mu.RUnlock()
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// ServeHTTP first picks up the marks that other processes, such as the
+// TUI, saved to the store, so that clients see the same state.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, err := s.store.Reload()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Publish sends a new or updated article to the clients of the event
+// stream. The monitor reports every article on each fetch, so those already
+// sent are skipped. Clients that do not keep up miss articles rather than
+// slowing the monitor down.
+func (s *Server) Publish(article c.Article) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	key := store.Key(article)
+	p, ok := s.published[key]
+	p.seen = now
+	if ok && !updatedAt(article).After(p.updated) {
+		s.published[key] = p
+		return
+	}
+	p.updated = updatedAt(article)
+	s.published[key] = p
+	for ch := range s.subscribers {
+		select {
+		case ch <- article:
+		default:
+		}
+	}
+}
+
+// sweep forgets the articles the monitor has not reported for publishedTTL,
+// as they have dropped out of their feeds. It runs once per publishedTTL.
+func (s *Server) sweep(now time.Time) {
+	if now.Sub(s.swept) < publishedTTL {
+		return
+	}
+	s.swept = now
+	for key, p := range s.published {
+		if now.Sub(p.seen) >= publishedTTL {
+			delete(s.published, key)
+		}
+	}
+}
+
+func (s *Server) subscribe() chan c.Article {
+	ch := make(chan c.Article, subscriberBuffer)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan c.Article) {
+	s.mu.Lock()
+	delete(s.subscribers, ch)
+	s.mu.Unlock()
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func updatedAt(a c.Article) time.Time {
+	if a.Updated == nil {
+		return time.Time{}
+	}
+	return *a.Updated
+}
+
+var errNotFound = errors.New("article not found")
+
+func errInvalid(name, value string) error {
+	return fmt.Errorf("invalid %s %q", name, value)
+}
@@ -0,0 +1,352 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+	mon "nned/internal/monitor"
+	"nned/internal/store"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	goFeed   = "https://go.dev/blog/feed.atom"
+	rustFeed = "https://blog.rust-lang.org/feed.xml"
+	goneFeed = "https://gone.example/feed"
+)
+
+func ago(d time.Duration) *time.Time {
+	t := time.Now().Add(-d).Truncate(time.Second)
+	return &t
+}
+
+// newTestServer serves a store on memfs holding, in this order, two articles
+// of the Go feed, one of the Rust feed, a starred article of a feed that is
+// no longer configured and one older than the last date. go2 is read and
+// rs1 is starred.
+func newTestServer(t *testing.T, config c.Config) (*Server, *store.Store) {
+	t.Helper()
+	return newTestServerOn(t, afero.NewMemMapFs(), config)
+}
+
+func newTestServerOn(t *testing.T, fs afero.Fs, config c.Config) (*Server, *store.Store) {
+	t.Helper()
+	st, err := store.New(fs, "/articles.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	articles := []c.Article{
+		{ID: "go1", Title: "Go 1.24", Description: "Generic type aliases", Link: "https://go.dev/1", FeedUrl: goFeed, Date: ago(time.Hour)},
+		{ID: "go2", Title: "Go tooling", Link: "https://go.dev/2", FeedUrl: goFeed, Date: ago(2 * time.Hour)},
+		{ID: "rs1", Title: "Rust 1.85", Link: "https://rust/1", FeedUrl: rustFeed, Date: ago(3 * time.Hour)},
+		{ID: "gone", Title: "Saved", FeedUrl: goneFeed, Date: ago(4 * time.Hour)},
+		{ID: "old", Title: "Old", FeedUrl: goFeed, Date: ago(30 * 24 * time.Hour)},
+	}
+	for _, a := range articles {
+		if _, _, err := st.Put(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st.SetRead("go2", true)
+	st.SetStarred("rs1", true)
+	st.SetStarred("gone", true)
+
+	monitor, _ := mon.NewMonitor(mon.Config{
+		Feeds: []c.Feed{
+			{Url: goFeed, Title: "Go Blog", Category: "Go"},
+			{Url: rustFeed, Title: "Rust"},
+		},
+		LastDate: time.Now().Add(-7 * 24 * time.Hour),
+		Store:    st,
+	})
+	t.Cleanup(monitor.Stop)
+	s := New(monitor, st)
+	s.Configure(config)
+	return s, st
+}
+
+// serve sends a request from the local host, as the API expects without
+// credentials.
+func serve(s *Server, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.RemoteAddr = "127.0.0.1:50000"
+	r.Host = "localhost:7777"
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func ids(articles []Article) string {
+	list := make([]string, len(articles))
+	for i, a := range articles {
+		list[i] = a.ID
+	}
+	return strings.Join(list, ",")
+}
+
+func TestListArticles(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{})
+	tests := []struct {
+		query string
+		want  string
+		total int
+	}{
+		{"", "go1,go2,rs1", 3},
+		{"?feed=rust", "rs1", 1},
+		{"?feed=" + goFeed, "go1,go2", 2},
+		{"?category=go", "go1,go2", 2},
+		{"?unread=true", "go1,rs1", 2},
+		{"?unread=false", "go2", 1},
+		{"?starred=true", "rs1", 1},
+		{"?since=90m", "go1", 1},
+		{"?q=aliases", "go1", 1},
+		{"?q=feed:rust+is:starred", "rs1", 1},
+		{"?limit=1&offset=1", "go2", 3},
+		{"?offset=10", "", 3},
+	}
+	for _, tt := range tests {
+		w := serve(s, "GET", "/api/articles"+tt.query, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d: %s", tt.query, w.Code, w.Body)
+			continue
+		}
+		list := decode[articleList](t, w)
+		if got := ids(list.Articles); got != tt.want || list.Total != tt.total {
+			t.Errorf("%s: got %q of %d, want %q of %d", tt.query, got, list.Total, tt.want, tt.total)
+		}
+	}
+}
+
+func TestListArticlesInvalid(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{})
+	for _, query := range []string{"?limit=many", "?offset=-1", "?since=yesterday", "?unread=maybe", "?q=is:new"} {
+		if w := serve(s, "GET", "/api/articles"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestGetArticle(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{})
+	w := serve(s, "GET", "/api/articles/go1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	a := decode[Article](t, w)
+	if a.Title != "Go 1.24" || a.Feed != "Go Blog" || a.Category != "Go" || a.Read {
+		t.Errorf("article = %+v", a)
+	}
+	w = serve(s, "GET", "/api/articles/gone", "")
+	if a := decode[Article](t, w); w.Code != http.StatusOK || a.Title != "Saved" || !a.Starred {
+		t.Errorf("stored article that is not shown: status %d, %+v", w.Code, a)
+	}
+	if w := serve(s, "GET", "/api/articles/nope", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown article: status %d, want 404", w.Code)
+	}
+}
+
+func TestUpdateArticle(t *testing.T) {
+	s, st := newTestServer(t, c.Config{})
+	w := serve(s, "PATCH", "/api/articles/go1", `{"read": true, "starred": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if a := decode[Article](t, w); !a.Read || !a.Starred {
+		t.Errorf("article = %+v", a)
+	}
+	if r, _ := st.Get("go1"); !r.Read || !r.Starred {
+		t.Errorf("stored = %+v", r)
+	}
+
+	w = serve(s, "PATCH", "/api/articles/go1", `{"starred": false}`)
+	if r, _ := st.Get("go1"); w.Code != http.StatusOK || !r.Read || r.Starred {
+		t.Errorf("partial update: status %d, stored %+v", w.Code, r)
+	}
+	if w := serve(s, "PATCH", "/api/articles/nope", `{"read": true}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown article: status %d, want 404", w.Code)
+	}
+	if w := serve(s, "PATCH", "/api/articles/go1", `read`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: status %d, want 400", w.Code)
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s, st := newTestServerOn(t, fs, c.Config{})
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tui, err := store.New(fs, "/articles.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tui.SetRead("go1", true)
+	if err := tui.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if a := decode[Article](t, serve(s, "GET", "/api/articles/go1", "")); !a.Read {
+		t.Error("read mark saved by another process not served")
+	}
+	serve(s, "PATCH", "/api/articles/go1", `{"starred": true}`)
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tui.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := tui.Get("go1"); !r.Read || !r.Starred {
+		t.Errorf("other process sees read %v, starred %v; want both", r.Read, r.Starred)
+	}
+}
+
+func TestListFeeds(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{})
+	w := serve(s, "GET", "/api/feeds", "")
+	feeds := decode[[]Feed](t, w)
+	if len(feeds) != 2 || feeds[0].Url != goFeed || feeds[0].Category != "Go" || feeds[1].Title != "Rust" {
+		t.Errorf("feeds = %+v", feeds)
+	}
+	if w := serve(s, "POST", "/api/refresh", ""); w.Code != http.StatusAccepted {
+		t.Errorf("refresh: status %d, want 202", w.Code)
+	}
+	if w := serve(s, "GET", "/api/refresh", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET refresh: status %d, want 405", w.Code)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{Sync: c.Sync{Username: "alice", APIKey: "secret", BaseURL: "https://nned.example/"}})
+	w := serve(s, "GET", "/api/feed?format=rss&category=go&limit=1&token=secret", "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if strings.Count(body, "<item>") != 1 || !strings.Contains(body, "Go 1.24") || !strings.Contains(body, "<title>nned: go</title>") {
+		t.Errorf("feed = %s", body)
+	}
+	if !strings.Contains(body, "<link>https://nned.example/api/feed?category=go&amp;format=rss&amp;limit=1</link>") {
+		t.Errorf("channel link is not the feed URL under the base URL: %s", body)
+	}
+	if w := serve(s, "GET", "/api/feed?format=csv&token=secret", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want 400", w.Code)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+
+	updated := time.Now()
+	article := c.Article{ID: "go1", Title: "Go 1.24, edited", FeedUrl: goFeed, Updated: &updated}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		s.mu.RLock()
+		n := len(s.subscribers)
+		s.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client never subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Publish(c.Article{ID: "go1", Title: "Go 1.24", FeedUrl: goFeed})
+	s.Publish(article)
+
+	buf := make([]byte, 4096)
+	n, err := resp.Body.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := string(buf[:n])
+	if !strings.HasPrefix(event, "event: article\nid: go1\ndata: ") || !strings.Contains(event, "Go 1.24, edited") {
+		t.Errorf("event = %q, want only the edited article", event)
+	}
+}
+
+func TestPublishedSweep(t *testing.T) {
+	s, _ := newTestServer(t, c.Config{})
+	long := time.Now().Add(-publishedTTL - time.Minute)
+	s.mu.Lock()
+	s.swept = long
+	for key, p := range s.published {
+		p.seen = long
+		s.published[key] = p
+	}
+	s.mu.Unlock()
+
+	s.Publish(c.Article{ID: "go1", Title: "Go 1.24", FeedUrl: goFeed})
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.published) != 1 {
+		t.Errorf("published = %v, want only go1 that is still reported", s.published)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	sync := c.Config{Sync: c.Sync{Username: "alice", APIKey: "secret"}}
+	tests := []struct {
+		name   string
+		config c.Config
+		remote string
+		host   string
+		target string
+		header string
+		want   int
+	}{
+		{"local without key", c.Config{}, "127.0.0.1:1", "localhost:7777", "/api/feeds", "", http.StatusOK},
+		{"local address without key", c.Config{}, "127.0.0.1:1", "127.0.0.1:7777", "/api/feeds", "", http.StatusOK},
+		{"local ipv6 without key", c.Config{}, "[::1]:1", "[::1]:7777", "/api/feeds", "", http.StatusOK},
+		{"remote without key", c.Config{}, "192.0.2.1:1", "localhost:7777", "/api/feeds", "", http.StatusForbidden},
+		{"rebound name without key", c.Config{}, "127.0.0.1:1", "attacker.example:7777", "/api/feeds", "", http.StatusForbidden},
+		{"no token", sync, "127.0.0.1:1", "localhost:7777", "/api/feeds", "", http.StatusUnauthorized},
+		{"bearer token", sync, "192.0.2.1:1", "nned.example", "/api/feeds", "Bearer secret", http.StatusOK},
+		{"token parameter", sync, "192.0.2.1:1", "nned.example", "/api/feed?token=secret", "", http.StatusOK},
+		{"wrong token", sync, "192.0.2.1:1", "nned.example", "/api/feeds", "Bearer guess", http.StatusUnauthorized},
+		{"basic auth", sync, "192.0.2.1:1", "nned.example", "/api/feeds", "Basic c2VjcmV0", http.StatusUnauthorized},
+		{"every endpoint", sync, "192.0.2.1:1", "nned.example", "/api/articles/go1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t, tt.config)
+			r := httptest.NewRequest("GET", tt.target, nil)
+			r.RemoteAddr = tt.remote
+			r.Host = tt.host
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/afero"
+)
+
+type Record struct {
+	Seq       int64     `json:"seq"`
+	Article   c.Article `json:"article"`
+	FirstSeen time.Time `json:"first_seen"`
+	Read      bool      `json:"read"`
+	Starred   bool      `json:"starred"`
+	StarredAt time.Time `json:"starred_at"`
+	Modified  time.Time `json:"modified"`
+}
+
+// Store keeps the articles and their read and starred state. Several
+// processes can share one store: each save merges what the others wrote
+// since, record by record, under a lock file.
+type Store struct {
+	fs      afero.Fs
+	path    string
+	mu      sync.RWMutex
+	records map[string]*Record
+	seqs    map[int64]string
+	seq     int64
+	removed map[string]bool
+	stamp   stamp
+	pending *time.Timer
+	err     error
+}
+
+// stamp identifies the version of the file the store last read or wrote.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (st stamp) matches(info os.FileInfo) bool {
+	return st.size == info.Size() && st.modTime.Equal(info.ModTime())
+}
+
+const (
+	flushDelay  = time.Second
+	lockTimeout = 5 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+type file struct {
+	Articles map[string]*Record `json:"articles"`
+}
+
+func DefaultPath() string {
+	return filepath.Join(xdg.DataHome, "nned", "articles.json")
+}
+
+// Open loads the store at path and drops the records of articles older than
+// lastDate that are not starred, since they are no longer shown.
+func Open(fs afero.Fs, path string, lastDate time.Time) (*Store, error) {
+	s, err := New(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	s.Prune(lastDate)
+	return s, nil
+}
+
+func New(fs afero.Fs, path string) (*Store, error) {
+	s := &Store{
+		fs:      fs,
+		path:    path,
+		records: make(map[string]*Record),
+		seqs:    make(map[int64]string),
+		removed: make(map[string]bool),
+	}
+	_, err := s.merge()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload merges the changes other processes saved since the store was last
+// read or written. It reports whether any record changed.
+func (s *Store) Reload() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.merge()
+}
+
+// merge reads the file when it changed and merges its records into the
+// store. The read and starred state of a record is taken from the side that
+// changed it last, its article from the side with the later version.
+func (s *Store) merge() (bool, error) {
+	info, err := s.fs.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to open store: %w", err)
+	}
+	if s.stamp.matches(info) {
+		return false, nil
+	}
+
+	handle, err := s.fs.Open(s.path)
+	if err != nil {
+		return false, fmt.Errorf("unable to open store: %w", err)
+	}
+	defer handle.Close()
+	var f file
+	err = json.NewDecoder(handle).Decode(&f)
+	if err != nil {
+		return false, fmt.Errorf("unable to read store: %w", err)
+	}
+	s.stamp = stamp{info.ModTime(), info.Size()}
+
+	changed := false
+	for key, fr := range f.Articles {
+		if s.removed[key] {
+			continue
+		}
+		r, ok := s.records[key]
+		if !ok {
+			s.records[key] = fr
+			changed = true
+			continue
+		}
+		if fr.Modified.After(r.Modified) {
+			r.Read, r.Starred, r.StarredAt, r.Modified = fr.Read, fr.Starred, fr.StarredAt, fr.Modified
+			changed = true
+		}
+		if Replaces(fr.Article, r.Article) {
+			r.Article = fr.Article
+			changed = true
+		}
+		if fr.FirstSeen.Before(r.FirstSeen) {
+			r.FirstSeen = fr.FirstSeen
+		}
+		r.Seq = fr.Seq
+	}
+	s.index(f.Articles)
+	return changed, nil
+}
+
+// index maps the sequence number of every record to its key. The numbers
+// in the file are kept; a record only this process knows whose number was
+// taken by another process in the meantime is numbered again.
+func (s *Store) index(saved map[string]*Record) {
+	s.seqs = make(map[int64]string, len(s.records))
+	for key, r := range s.records {
+		s.seq = max(s.seq, r.Seq)
+		if _, ok := saved[key]; ok {
+			s.seqs[r.Seq] = key
+		}
+	}
+	renumber := make([]string, 0)
+	for key, r := range s.records {
+		if _, ok := saved[key]; ok {
+			continue
+		}
+		if _, taken := s.seqs[r.Seq]; taken || r.Seq == 0 {
+			renumber = append(renumber, key)
+			continue
+		}
+		s.seqs[r.Seq] = key
+	}
+	sort.Strings(renumber)
+	for _, key := range renumber {
+		s.seq++
+		s.records[key].Seq = s.seq
+		s.seqs[s.seq] = key
+	}
+}
+
+// Key returns the identity of an article in the store.
+func Key(a c.Article) string {
+	return a.ID
+}
+
+// Replaces reports whether a is a later version of the stored article b,
+// either updated by its feed or with its full text extracted.
+func Replaces(a, b c.Article) bool {
+	if b.Content == "" && a.Content != "" {
+		return true
+	}
+	if a.Updated == nil {
+		return false
+	}
+	return b.Updated == nil || a.Updated.After(*b.Updated)
+}
+
+// Put records an article the first time it is seen and returns its stored
+// state. The bool is true when the article was not known before. When a
+// known article has changed, the new version replaces the stored one and is
+// marked as updated, unless the feed dated the update itself. Extracted
+// content is kept when a later fetch has none. New articles arrive in
+// bursts, so writing them to disk is deferred briefly.
+func (s *Store) Put(a c.Article) (Record, bool, error) {
+	key := Key(a)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.records[key]; ok {
+		if !changed(r.Article, a) {
+			if r.Article.Content == "" && a.Content != "" {
+				r.Article.Content = a.Content
+				s.schedule()
+			}
+			return *r, false, s.takeErr()
+		}
+		if a.Content == "" {
+			a.Content = r.Article.Content
+		}
+		if a.Updated == nil {
+			now := time.Now()
+			a.Updated = &now
+		}
+		r.Article = a
+		s.schedule()
+		return *r, false, s.takeErr()
+	}
+	s.seq++
+	r := &Record{
+		Seq:       s.seq,
+		Article:   a,
+		FirstSeen: time.Now(),
+	}
+	s.records[key] = r
+	s.seqs[r.Seq] = key
+	s.schedule()
+	return *r, true, s.takeErr()
+}
+
+// schedule writes the store to disk shortly, so that a burst of changes is
+// saved once. Close writes what is still pending.
+func (s *Store) schedule() {
+	if s.pending == nil {
+		s.pending = time.AfterFunc(flushDelay, s.flush)
+	}
+}
+
+// takeErr returns the error of the last deferred flush once, so that it is
+// reported by a single caller rather than by every change until the next
+// flush.
+func (s *Store) takeErr() error {
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// changed reports whether the feed has edited an article since it was
+// stored. Content is only compared when it was extracted both times; the
+// first extraction fills in the article rather than updating it.
+func changed(old, new c.Article) bool {
+	if old.Title != new.Title || old.Description != new.Description || old.Link != new.Link {
+		return true
+	}
+	return old.Content != "" && new.Content != "" && new.Content != old.Content
+}
+
+// Prune removes the records of articles older than before that are not
+// starred and returns how many were removed.
+func (s *Store) Prune(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for key, r := range s.records {
+		if !r.Starred && dateOf(*r).Before(before) {
+			delete(s.records, key)
+			delete(s.seqs, r.Seq)
+			s.removed[key] = true
+			removed++
+		}
+	}
+	if removed > 0 {
+		s.schedule()
+	}
+	return removed
+}
+
+// Close writes any deferred changes to disk.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending != nil {
+		s.pending.Stop()
+		s.pending = nil
+	}
+	return s.save()
+}
+
+func (s *Store) Get(key string) (Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.records[key]
+	if !ok {
+		return Record{}, false
+	}
+	return *r, true
+}
+
+// KeyOf returns the key of the record with the given sequence number.
+// Sequence numbers grow with every new article, for clients that need
+// numeric identifiers.
+func (s *Store) KeyOf(seq int64) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.seqs[seq]
+	return key, ok
+}
+
+// Records returns every stored record, newest first.
+func (s *Store) Records() []Record {
+	s.mu.RLock()
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, *r)
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return dateOf(records[i]).After(dateOf(records[j]))
+	})
+	return records
+}
+
+func (s *Store) SetRead(key string, read bool) error {
+	return s.update(key, func(r *Record) { r.Read = read })
+}
+
+// SetReadMany changes the read state of several articles at once. Unknown
+// keys are skipped.
+func (s *Store) SetReadMany(keys []string, read bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if r, ok := s.records[key]; ok && r.Read != read {
+			r.Read = read
+			r.Modified = time.Now()
+		}
+	}
+	s.schedule()
+	return s.takeErr()
+}
+
+func (s *Store) SetStarred(key string, starred bool) error {
+	return s.update(key, func(r *Record) {
+		r.Starred = starred
+		r.StarredAt = time.Time{}
+		if starred {
+			r.StarredAt = time.Now()
+		}
+	})
+}
+
+func (s *Store) update(key string, fn func(r *Record)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[key]
+	if !ok {
+		return fmt.Errorf("unknown article %s", key)
+	}
+	fn(r)
+	r.Modified = time.Now()
+	s.schedule()
+	return s.takeErr()
+}
+
+func (s *Store) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = nil
+	s.err = s.save()
+}
+
+// save merges what other processes saved in the meantime and writes the
+// store. The lock keeps another process from writing between the merge and
+// the rename.
+func (s *Store) save() error {
+	err := s.fs.MkdirAll(filepath.Dir(s.path), 0o755)
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = s.merge()
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	handle, err := s.fs.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	err = json.NewEncoder(handle).Encode(file{Articles: s.records})
+	handle.Close()
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	err = s.fs.Rename(tmp, s.path)
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	info, err := s.fs.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	s.stamp = stamp{info.ModTime(), info.Size()}
+	s.removed = make(map[string]bool)
+	return nil
+}
+
+// lock creates the lock file of the store, waiting while another process
+// holds it. A lock older than staleLock was left by a process that died
+// and is taken over.
+func (s *Store) lock() (func(), error) {
+	path := s.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		handle, err := s.fs.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			handle.Close()
+			return func() { s.fs.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("unable to lock store: %w", err)
+		}
+		if info, err := s.fs.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			s.fs.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("unable to lock store: %s is held by another process", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func dateOf(r Record) time.Time {
+	if r.Article.Date != nil {
+		return *r.Article.Date
+	}
+	return r.FirstSeen
+}
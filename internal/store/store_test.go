@@ -0,0 +1,359 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	c "nned/internal/common"
+
+	"github.com/spf13/afero"
+)
+
+const testPath = "/data/nned/articles.json"
+
+func newTestStore(t *testing.T, fs afero.Fs) *Store {
+	t.Helper()
+	s, err := New(fs, testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func writeFile(t *testing.T, fs afero.Fs, records map[string]*Record) {
+	t.Helper()
+	data, err := json.Marshal(file{Articles: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = afero.WriteFile(fs, testPath, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func date(s string) *time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestPut(t *testing.T) {
+	s := newTestStore(t, afero.NewMemMapFs())
+	a := c.Article{ID: "a", Title: "Title"}
+	_, isNew, err := s.Put(a)
+	if err != nil || !isNew {
+		t.Fatalf("first Put = %v, %v; want true, nil", isNew, err)
+	}
+	s.SetRead(Key(a), true)
+
+	r, isNew, err := s.Put(a)
+	if err != nil || isNew {
+		t.Fatalf("second Put = %v, %v; want false, nil", isNew, err)
+	}
+	if !r.Read {
+		t.Error("second Put lost the read state")
+	}
+	if _, isNew, _ := s.Put(c.Article{ID: "b", Title: "Title"}); !isNew {
+		t.Error("article with another ID counted as known")
+	}
+}
+
+func TestPutEdited(t *testing.T) {
+	base := c.Article{ID: "a", Title: "Title", Description: "desc", Link: "https://example.com/a"}
+	withContent := base
+	withContent.Content = "full text"
+	retitled := base
+	retitled.Title = "New title"
+	dated := retitled
+	dated.Updated = date("2024-05-01")
+
+	tests := []struct {
+		name        string
+		first       c.Article
+		second      c.Article
+		wantUpdated bool
+		wantContent string
+		wantTitle   string
+	}{
+		{"unchanged", base, base, false, "", "Title"},
+		{"content extracted", base, withContent, false, "full text", "Title"},
+		{"content kept", withContent, base, false, "full text", "Title"},
+		{"edited", base, retitled, true, "", "New title"},
+		{"edited keeps content", withContent, retitled, true, "full text", "New title"},
+		{"dated by feed", base, dated, true, "", "New title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, afero.NewMemMapFs())
+			r, isNew, err := s.Put(tt.first)
+			if err != nil || !isNew || r.Seq != 1 {
+				t.Fatalf("first Put = %d, %v, %v; want 1, true, nil", r.Seq, isNew, err)
+			}
+			r, isNew, err = s.Put(tt.second)
+			if err != nil || isNew {
+				t.Fatalf("second Put = %v, %v; want false, nil", isNew, err)
+			}
+			if r.Seq != 1 {
+				t.Errorf("Seq = %d, want 1", r.Seq)
+			}
+			if (r.Article.Updated != nil) != tt.wantUpdated {
+				t.Errorf("Updated = %v, want set %v", r.Article.Updated, tt.wantUpdated)
+			}
+			if r.Article.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", r.Article.Content, tt.wantContent)
+			}
+			if r.Article.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", r.Article.Title, tt.wantTitle)
+			}
+			if tt.second.Updated != nil && !r.Article.Updated.Equal(*tt.second.Updated) {
+				t.Errorf("Updated = %v, want the feed's %v", r.Article.Updated, tt.second.Updated)
+			}
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, map[string]*Record{
+		"a": {Seq: 5, Article: c.Article{ID: "a"}},
+		"b": {Seq: 2, Article: c.Article{ID: "b"}},
+	})
+	s := newTestStore(t, fs)
+
+	for seq, key := range map[int64]string{5: "a", 2: "b"} {
+		got, ok := s.KeyOf(seq)
+		if !ok || got != key {
+			t.Errorf("KeyOf(%d) = %q, %v; want %q", seq, got, ok, key)
+		}
+	}
+	r, _, _ := s.Put(c.Article{ID: "new"})
+	if r.Seq != 6 {
+		t.Errorf("new record Seq = %d, want 6", r.Seq)
+	}
+	if _, ok := s.KeyOf(7); ok {
+		t.Error("KeyOf found an unused sequence number")
+	}
+}
+
+func TestRecords(t *testing.T) {
+	s := newTestStore(t, afero.NewMemMapFs())
+	s.Put(c.Article{ID: "1", Title: "old", Date: date("2024-01-01")})
+	s.Put(c.Article{ID: "2", Title: "new", Date: date("2024-03-01")})
+	s.Put(c.Article{ID: "3", Title: "mid", Date: date("2024-02-01")})
+
+	var titles []string
+	for _, r := range s.Records() {
+		titles = append(titles, r.Article.Title)
+	}
+	if got := len(titles); got != 3 || titles[0] != "new" || titles[1] != "mid" || titles[2] != "old" {
+		t.Errorf("Records = %v, want newest first", titles)
+	}
+}
+
+func TestKeyOf(t *testing.T) {
+	s := newTestStore(t, afero.NewMemMapFs())
+	for _, id := range []string{"a", "b", "c"} {
+		s.Put(c.Article{ID: id, Date: date("2024-01-01")})
+	}
+	s.SetStarred("b", true)
+	s.Prune(*date("2024-06-01"))
+
+	tests := []struct {
+		seq  int64
+		key  string
+		want bool
+	}{
+		{1, "", false},
+		{2, "b", true},
+		{3, "", false},
+		{4, "", false},
+	}
+	for _, tt := range tests {
+		key, ok := s.KeyOf(tt.seq)
+		if key != tt.key || ok != tt.want {
+			t.Errorf("KeyOf(%d) = %q, %v; want %q, %v", tt.seq, key, ok, tt.key, tt.want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := newTestStore(t, afero.NewMemMapFs())
+	s.Put(c.Article{ID: "old", Date: date("2024-01-01")})
+	s.Put(c.Article{ID: "starred", Date: date("2024-01-01")})
+	s.Put(c.Article{ID: "recent", Date: date("2024-03-01")})
+	s.SetStarred("starred", true)
+
+	if n := s.Prune(*date("2024-02-01")); n != 1 {
+		t.Errorf("Prune = %d, want 1", n)
+	}
+	for key, want := range map[string]bool{"old": false, "starred": true, "recent": true} {
+		if _, ok := s.Get(key); ok != want {
+			t.Errorf("Get(%q) found %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestFlushError(t *testing.T) {
+	s, err := New(afero.NewReadOnlyFs(afero.NewMemMapFs()), testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Put(c.Article{ID: "a"})
+	s.flush()
+	if _, _, err := s.Put(c.Article{ID: "b"}); err == nil {
+		t.Error("the failed flush was not reported")
+	}
+	if _, _, err := s.Put(c.Article{ID: "c"}); err != nil {
+		t.Errorf("the failed flush was reported again: %v", err)
+	}
+}
+
+func TestCloseSaves(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s, err := New(fs, testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := c.Article{ID: "a", Title: "A"}
+	s.Put(a)
+	s.SetStarred(Key(a), true)
+	if ok, _ := afero.Exists(fs, testPath); ok {
+		t.Error("changes were written before the flush delay")
+	}
+	err = s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s = newTestStore(t, fs)
+	r, ok := s.Get(Key(a))
+	if !ok || !r.Starred || r.Article.Title != "A" {
+		t.Errorf("reloaded record = %+v, %v", r, ok)
+	}
+	if err := s.SetRead("missing", true); err == nil {
+		t.Error("SetRead of an unknown key succeeded")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, testPath, []byte("{"), 0o644)
+	if _, err := New(fs, testPath); err == nil {
+		t.Error("New read a corrupt store")
+	}
+}
+
+func TestReplaces(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b c.Article
+		want bool
+	}{
+		{"same", c.Article{}, c.Article{}, false},
+		{"content added", c.Article{Content: "x"}, c.Article{}, true},
+		{"content dropped", c.Article{}, c.Article{Content: "x"}, false},
+		{"first update", c.Article{Updated: date("2024-01-02")}, c.Article{}, true},
+		{"newer update", c.Article{Updated: date("2024-01-02")}, c.Article{Updated: date("2024-01-01")}, true},
+		{"older update", c.Article{Updated: date("2024-01-01")}, c.Article{Updated: date("2024-01-02")}, false},
+	}
+	for _, tt := range tests {
+		if got := Replaces(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Replaces = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShared(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tui := newTestStore(t, fs)
+	serve := newTestStore(t, fs)
+
+	tui.Put(c.Article{ID: "a", Title: "A"})
+	tui.Put(c.Article{ID: "b", Title: "B"})
+	if err := tui.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := serve.Reload(); err != nil || !changed {
+		t.Fatalf("Reload = %v, %v; want the saved articles", changed, err)
+	}
+	if changed, _ := serve.Reload(); changed {
+		t.Error("Reload of an unchanged file changed the store")
+	}
+
+	serve.SetRead("a", true)
+	serve.Put(c.Article{ID: "c", Title: "C"})
+	if err := serve.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tui.SetStarred("b", true)
+	tui.Put(c.Article{ID: "d", Title: "D"})
+	if err := tui.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newTestStore(t, fs)
+	for _, st := range []*Store{tui, reopened} {
+		if r, _ := st.Get("a"); !r.Read {
+			t.Error("read mark of the other process was lost")
+		}
+		if r, _ := st.Get("b"); !r.Starred {
+			t.Error("star was lost")
+		}
+		seqs := make(map[int64]bool)
+		for _, key := range []string{"a", "b", "c", "d"} {
+			r, ok := st.Get(key)
+			if !ok {
+				t.Fatalf("%s is missing", key)
+			}
+			if seqs[r.Seq] {
+				t.Errorf("%s has the taken sequence number %d", key, r.Seq)
+			}
+			seqs[r.Seq] = true
+			if got, _ := st.KeyOf(r.Seq); got != key {
+				t.Errorf("KeyOf(%d) = %q, want %q", r.Seq, got, key)
+			}
+		}
+	}
+
+	// The later change wins, whichever process saves last.
+	tui.SetRead("a", false)
+	time.Sleep(time.Millisecond)
+	serve.SetRead("a", true)
+	serve.Close()
+	tui.Close()
+	if r, _ := tui.Get("a"); !r.Read {
+		t.Error("an older change overwrote a newer one")
+	}
+}
+
+func TestPruneIsNotUndone(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s := newTestStore(t, fs)
+	s.Put(c.Article{ID: "old", Date: date("2024-01-01")})
+	s.Close()
+	s.Prune(*date("2024-02-01"))
+	s.Close()
+	if _, ok := newTestStore(t, fs).Get("old"); ok {
+		t.Error("the saved copy brought back a pruned record")
+	}
+}
+
+func TestLock(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	s := newTestStore(t, fs)
+	s.Put(c.Article{ID: "a"})
+	afero.WriteFile(fs, testPath+".lock", nil, 0o644)
+	old := time.Now().Add(-2 * staleLock)
+	fs.Chtimes(testPath+".lock", old, old)
+	if err := s.Close(); err != nil {
+		t.Fatalf("a stale lock was not taken over: %v", err)
+	}
+	if ok, _ := afero.Exists(fs, testPath+".lock"); ok {
+		t.Error("the lock was not released")
+	}
+}
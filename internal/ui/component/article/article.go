@@ -1,69 +1,129 @@
 package article
 
 import (
+	"fmt"
+
 	c "nned/internal/common"
-	"nned/internal/ui/util"
 
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type Model struct {
-	article c.Article
-	width   int
-	height  int
+	article  c.Article
+	width    int
+	height   int
+	focused  bool
+	viewport viewport.Model
 }
 
 type SetArticleMsg *c.Article
 
 func NewModel() *Model {
+	vp := viewport.New(78, 76)
+	vp.Style = lipgloss.NewStyle().PaddingLeft(1)
 	return &Model{
-		article: c.Article{},
-		width:   80,
-		height:  80,
+		article:  c.Article{},
+		width:    80,
+		height:   80,
+		viewport: vp,
 	}
 }
 
 func (m *Model) Init() tea.Cmd { return nil }
 
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case SetArticleMsg:
 		m.article = *msg
+		m.refresh()
+		m.viewport.GotoTop()
 		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "home", "g":
+			m.viewport.GotoTop()
+			return m, nil
+		case "end", "G":
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	case tea.MouseMsg:
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
 	}
 	return m, nil
 }
 
 func (m *Model) View() string {
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#BBBBBB")).Margin(1)
-	descriptionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#DDDDDD")).Margin(1)
+	borderColor := lipgloss.Color("#444444")
+	if m.focused {
+		borderColor = lipgloss.Color("#FFAF00")
+	}
+	contentStyle := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(borderColor).
+		Width(m.width - 1).
+		Height(m.height - 2)
+
+	if m.article.Title == "" {
+		return contentStyle.Render(lipgloss.PlaceHorizontal(m.width, lipgloss.Left, "Select article to read"))
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, m.header(), m.viewport.View())
+	return contentStyle.Render(content)
+}
+
+func (m *Model) header() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#BBBBBB")).Margin(1).Width(m.width - 3)
 	sourceStyle := lipgloss.NewStyle().Background(lipgloss.Color(m.article.SourceColor)).Margin(0, 1)
 	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#999999"))
-	var titleBlock, descriptionBlock, sourceBlock, dateBlock string
 
-	if m.article.Title == "" {
-		titleBlock = lipgloss.PlaceHorizontal(m.width, lipgloss.Left, "Select article to read")
-		descriptionBlock = ""
-		sourceBlock = ""
-		dateBlock = ""
-	} else {
-		titleBlock = lipgloss.PlaceHorizontal(m.width, lipgloss.Left, titleStyle.Render(m.article.Title))
-		description, err := util.GetStringFromHTML(m.article.Description)
-		if err != nil {
-			description = ""
-		}
-		descriptionBlock = lipgloss.PlaceHorizontal(m.width, lipgloss.Left, descriptionStyle.Render(description))
-		sourceBlock = lipgloss.PlaceHorizontal(len(m.article.SourceTitle), lipgloss.Left, sourceStyle.Render(m.article.SourceTitle))
-		dateBlock = lipgloss.PlaceHorizontal(m.width/2, lipgloss.Left, timeStyle.Render(m.article.Date.Format("Mon, Jan 2, 2006")))
+	titleBlock := titleStyle.Render(m.article.Title)
+	sourceBlock := sourceStyle.Render(m.article.SourceTitle)
+	dateBlock := ""
+	if m.article.Date != nil {
+		dateBlock = timeStyle.Render(m.article.Date.Format("Mon, Jan 2, 2006"))
+	}
+	scroll := ""
+	if !m.viewport.AtTop() || !m.viewport.AtBottom() {
+		scroll = timeStyle.MarginLeft(2).Render(fmt.Sprintf("%.f%%", m.viewport.ScrollPercent()*100))
 	}
-	content := lipgloss.JoinVertical(lipgloss.Left, titleBlock, lipgloss.JoinHorizontal(lipgloss.Top, sourceBlock, dateBlock), descriptionBlock)
-	contentStyle := lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Width(m.width - 1).Height(m.height - 2)
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleBlock,
+		lipgloss.JoinHorizontal(lipgloss.Top, sourceBlock, dateBlock, scroll),
+		"",
+	)
+}
 
-	return contentStyle.Render(content)
+// refresh renders the article body and sizes the viewport to the space
+// left under the header.
+func (m *Model) refresh() {
+	m.viewport.Width = m.width - 3
+	m.viewport.Height = max(m.height-2-lipgloss.Height(m.header()), 1)
+	body := m.article.Content
+	if body == "" {
+		body = m.article.Description
+	}
+	m.viewport.SetContent(Render(body, m.viewport.Width-2))
 }
 
 func (m *Model) SetDimensions(width, height int) {
+	if width == m.width && height == m.height {
+		return
+	}
 	m.width = width
 	m.height = height
+	m.refresh()
+}
+
+func (m *Model) SetFocus(focused bool) {
+	m.focused = focused
+}
+
+func (m *Model) Focused() bool {
+	return m.focused
 }
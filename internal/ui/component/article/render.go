@@ -0,0 +1,271 @@
+package article
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"nned/internal/ui/util"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	headingStyle = util.NewStyle("#FFAF00", "", true)
+	boldStyle    = lipgloss.NewStyle().Bold(true)
+	italicStyle  = lipgloss.NewStyle().Italic(true)
+	codeStyle    = util.NewStyle("#D7AF87", "", false)
+	linkStyle    = util.NewStyle("#5FAFFF", "", false)
+	quoteStyle   = util.NewStyle("#666666", "", false)
+	refStyle     = util.NewStyle("#999999", "", false)
+)
+
+type list struct {
+	ordered bool
+	count   int
+}
+
+type block struct {
+	text  string
+	tight bool
+}
+
+// renderer turns feed HTML into wrapped, styled terminal text. Inline
+// content is collected until a block element ends it; links are replaced
+// with numbered references listed at the end.
+type renderer struct {
+	width  int
+	blocks []block
+	inline strings.Builder
+	links  []string
+	lists  []list
+	quote  int
+	bullet string
+}
+
+func Render(s string, width int) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return wordwrap.String(sanitize(s), width)
+	}
+	r := &renderer{width: max(width, 10)}
+	r.walk(doc)
+	r.flush(lipgloss.NewStyle())
+
+	if len(r.links) > 0 {
+		refs := make([]string, 0, len(r.links))
+		for i, link := range r.links {
+			refs = append(refs, refStyle.Render(fmt.Sprintf("[%d] ", i+1))+wrap.String(link, r.width-5))
+		}
+		r.blocks = append(r.blocks, block{text: strings.Join(refs, "\n")})
+	}
+
+	var sb strings.Builder
+	for i, b := range r.blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+			if !b.tight {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString(b.text)
+	}
+	return sb.String()
+}
+
+func (r *renderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Noscript:
+	case atom.Br:
+		r.inline.WriteString("\n")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.flush(lipgloss.NewStyle())
+		r.children(n)
+		r.flush(headingStyle)
+	case atom.Ul, atom.Ol:
+		r.flush(lipgloss.NewStyle())
+		r.lists = append(r.lists, list{ordered: n.DataAtom == atom.Ol})
+		r.children(n)
+		r.flush(lipgloss.NewStyle())
+		r.lists = r.lists[:len(r.lists)-1]
+	case atom.Li:
+		r.flush(lipgloss.NewStyle())
+		if len(r.lists) > 0 {
+			l := &r.lists[len(r.lists)-1]
+			l.count++
+			r.bullet = "• "
+			if l.ordered {
+				r.bullet = fmt.Sprintf("%d. ", l.count)
+			}
+		}
+		r.children(n)
+		r.flush(lipgloss.NewStyle())
+	case atom.Blockquote:
+		r.flush(lipgloss.NewStyle())
+		r.quote++
+		r.children(n)
+		r.flush(lipgloss.NewStyle())
+		r.quote--
+	case atom.Pre:
+		r.flush(lipgloss.NewStyle())
+		lines := strings.Split(strings.TrimRight(sanitize(textOf(n)), "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = codeStyle.Render("  " + line)
+		}
+		r.add(strings.Join(lines, "\n"), false)
+	case atom.Hr:
+		r.flush(lipgloss.NewStyle())
+		r.add(quoteStyle.Render(strings.Repeat("─", r.textWidth())), false)
+	case atom.Img:
+		if alt := sanitize(attr(n, "alt")); alt != "" {
+			r.inline.WriteString(quoteStyle.Render("[image: " + alt + "]"))
+		}
+	case atom.A:
+		r.children(n)
+		href := sanitize(attr(n, "href"))
+		if href != "" && !strings.HasPrefix(href, "#") {
+			r.links = append(r.links, href)
+			r.inline.WriteString(linkStyle.Render(fmt.Sprintf("[%d]", len(r.links))))
+		}
+	case atom.B, atom.Strong:
+		r.styled(n, boldStyle)
+	case atom.I, atom.Em:
+		r.styled(n, italicStyle)
+	case atom.Code:
+		r.styled(n, codeStyle)
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer,
+		atom.Figure, atom.Figcaption, atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd:
+		r.flush(lipgloss.NewStyle())
+		r.children(n)
+		r.flush(lipgloss.NewStyle())
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+func (r *renderer) text(s string) {
+	s = sanitize(s)
+	collapsed := strings.Join(strings.Fields(s), " ")
+	if collapsed == "" {
+		if s != "" && r.inline.Len() > 0 {
+			r.inline.WriteString(" ")
+		}
+		return
+	}
+	if s[0] == ' ' || s[0] == '\n' || s[0] == '\t' {
+		if r.inline.Len() > 0 {
+			collapsed = " " + collapsed
+		}
+	}
+	last := s[len(s)-1]
+	if last == ' ' || last == '\n' || last == '\t' {
+		collapsed += " "
+	}
+	r.inline.WriteString(collapsed)
+}
+
+// styled renders the inline content of n and applies style to it.
+func (r *renderer) styled(n *html.Node, style lipgloss.Style) {
+	before := r.inline.String()
+	r.inline.Reset()
+	r.children(n)
+	inner := r.inline.String()
+	r.inline.Reset()
+	r.inline.WriteString(before)
+	if strings.TrimSpace(inner) != "" {
+		r.inline.WriteString(style.Render(inner))
+	}
+}
+
+// flush wraps the pending inline content into a block, prefixed with the
+// current list bullet and blockquote bars.
+func (r *renderer) flush(style lipgloss.Style) {
+	text := strings.TrimSpace(r.inline.String())
+	r.inline.Reset()
+	if text == "" {
+		return
+	}
+
+	indent := strings.Repeat("  ", max(len(r.lists)-1, 0))
+	first, rest := indent, indent
+	if r.bullet != "" {
+		first += r.bullet
+		rest += strings.Repeat(" ", lipgloss.Width(r.bullet))
+	}
+	quote := strings.Repeat(quoteStyle.Render("│ "), r.quote)
+
+	width := r.width - lipgloss.Width(first) - 2*r.quote
+	lines := strings.Split(wrap.String(wordwrap.String(text, width), width), "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		lines[i] = quote + prefix + style.Render(strings.TrimRight(line, " "))
+	}
+	r.add(strings.Join(lines, "\n"), r.bullet != "" && len(r.blocks) > 0)
+	r.bullet = ""
+}
+
+func (r *renderer) add(text string, tight bool) {
+	r.blocks = append(r.blocks, block{text: text, tight: tight})
+}
+
+func (r *renderer) textWidth() int {
+	return max(r.width-2*r.quote, 1)
+}
+
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// sanitize drops control characters other than newlines and tabs, so that
+// feed content cannot send escape sequences to the terminal.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
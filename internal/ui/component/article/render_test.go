@@ -0,0 +1,96 @@
+package article
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		width int
+		want  string
+	}{
+		{
+			"paragraphs", "<p>First   paragraph</p>\n<p>Second</p>", 40,
+			"First paragraph\n\nSecond",
+		},
+		{
+			"wrapped", "<p>one two three four five</p>", 10,
+			"one two\nthree four\nfive",
+		},
+		{
+			"heading", "<h2>Title</h2><div>Body</div>", 40,
+			"Title\n\nBody",
+		},
+		{
+			"inline", "<p>a <b>bold</b> and <em>loud</em> <code>x := 1</code><br>next</p>", 40,
+			"a bold and loud x := 1\nnext",
+		},
+		{
+			"unordered list", "<p>Intro</p><ul><li>one</li><li>two</li></ul>", 40,
+			"Intro\n• one\n• two",
+		},
+		{
+			"ordered list", "<ol><li>one</li><li>two</li></ol>", 40,
+			"1. one\n2. two",
+		},
+		{
+			"nested list", "<ul><li>one<ul><li>inner</li></ul></li></ul>", 40,
+			"• one\n  • inner",
+		},
+		{
+			"links", `<p>see <a href="https://go.dev">Go</a> and <a href="#top">top</a></p>`, 40,
+			"see Go[1] and top\n\n[1] https://go.dev",
+		},
+		{
+			"image", `<p><img src="x.png" alt="a gopher"></p>`, 40,
+			"[image: a gopher]",
+		},
+		{
+			"blockquote", "<blockquote><p>quoted</p></blockquote>", 40,
+			"│ quoted",
+		},
+		{
+			"pre", "<pre>func main() {\n\tgo run()\n}\n</pre>", 40,
+			"  func main() {\n      go run()\n  }",
+		},
+		{
+			"script", "<p>text</p><script>alert(1)</script>", 40,
+			"text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.html, tt.width); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderStripsControl(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"text", "<p>safe\x1b]52;c;cHduZWQ=\x07 text\x1b[2J</p>", "safe]52;c;cHduZWQ= text[2J"},
+		{"c1", "<p>a\u009b31mb</p>", "a31mb"},
+		{"pre", "<pre>a\x1b[31m b\nc</pre>", "  a[31m b\n  c"},
+		{"link", `<a href="https://x/` + "\x1b[0m" + `">x</a>`, "x[1]\n\n[1] https://x/[0m"},
+		{"alt", "<img alt=\"a\x1b[1mb\">", "[image: a[1mb]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.html, 80)
+			if strings.ContainsAny(got, "\x1b\x07\u009b") {
+				t.Errorf("Render() = %q, contains control characters", got)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package errlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	c "nned/internal/common"
+	"nned/internal/ui/util"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxErrors = 200
+
+var (
+	timeStyle  = util.NewStyle("#666666", "", false)
+	urlStyle   = util.NewStyle("#EBEBEB", "", true)
+	causeStyle = util.NewStyle("#FF5F5F", "", false)
+)
+
+type ErrorMsg struct {
+	Err error
+}
+
+type Model struct {
+	errors   []c.FeedError
+	viewport viewport.Model
+	width    int
+	height   int
+}
+
+func Report(err error) tea.Cmd {
+	return func() tea.Msg {
+		return ErrorMsg{Err: err}
+	}
+}
+
+func NewModel() *Model {
+	return &Model{
+		errors:   make([]c.FeedError, 0),
+		viewport: viewport.New(80, 20),
+		width:    80,
+		height:   20,
+	}
+}
+
+func (m *Model) Init() tea.Cmd { return nil }
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case ErrorMsg:
+		var feedErr c.FeedError
+		if !errors.As(msg.Err, &feedErr) {
+			feedErr = c.FeedError{Time: time.Now(), Err: msg.Err}
+		}
+		m.errors = append(m.errors, feedErr)
+		if len(m.errors) > maxErrors {
+			m.errors = m.errors[len(m.errors)-maxErrors:]
+		}
+		m.viewport.SetContent(m.content())
+		return m, nil
+	case tea.KeyMsg, tea.MouseMsg:
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *Model) Len() int {
+	return len(m.errors)
+}
+
+// Status is a one line summary of the most recent error for the footer.
+func (m *Model) Status() string {
+	if len(m.errors) == 0 {
+		return ""
+	}
+	last := m.errors[len(m.errors)-1]
+	return fmt.Sprintf("✗ %d error(s), last %s: %s", len(m.errors), last.Time.Format("15:04"), last.Error())
+}
+
+func (m *Model) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = width - 2
+	m.viewport.Height = height - 3
+	m.viewport.SetContent(m.content())
+}
+
+func (m *Model) View() string {
+	title := fmt.Sprintf(" Errors (%d)  e/esc: close ↑/↓: scroll", len(m.errors))
+	style := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#FF5F5F")).
+		Width(m.width - 2).
+		Height(m.height - 2)
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, title, m.viewport.View()))
+}
+
+func (m *Model) content() string {
+	if len(m.errors) == 0 {
+		return "No errors"
+	}
+	lines := make([]string, 0, len(m.errors)*2)
+	for i := len(m.errors) - 1; i >= 0; i-- {
+		e := m.errors[i]
+		url := e.Url
+		if url == "" {
+			url = "nned"
+		}
+		lines = append(lines,
+			timeStyle.Render(e.Time.Format("2006-01-02 15:04:05"))+" "+urlStyle.Render(url),
+			"  "+causeStyle.Render(e.Err.Error()),
+		)
+	}
+	return strings.Join(lines, "\n")
+}
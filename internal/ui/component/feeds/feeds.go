@@ -0,0 +1,179 @@
+package feeds
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	mon "nned/internal/monitor"
+	"nned/internal/ui/util"
+
+	grid "github.com/achannarasappa/term-grid"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	headerStyle = util.NewStyle("#999999", "", true)
+	cellStyle   = util.NewStyle("#EBEBEB", "", false)
+	dimStyle    = util.NewStyle("#666666", "", false)
+	healthStyle = map[mon.Health]lipgloss.Style{
+		mon.HealthUnknown:  util.NewStyle("#666666", "", false),
+		mon.HealthOK:       util.NewStyle("#5FD75F", "", false),
+		mon.HealthDegraded: util.NewStyle("#FFD75F", "", false),
+		mon.HealthFailing:  util.NewStyle("#FF5F5F", "", false),
+	}
+)
+
+type SetStatusMsg []mon.FeedStatus
+
+type Model struct {
+	statuses []mon.FeedStatus
+	viewport viewport.Model
+	width    int
+	height   int
+}
+
+func NewModel() *Model {
+	return &Model{
+		statuses: make([]mon.FeedStatus, 0),
+		viewport: viewport.New(80, 20),
+		width:    80,
+		height:   20,
+	}
+}
+
+func (m *Model) Init() tea.Cmd { return nil }
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case SetStatusMsg:
+		m.statuses = msg
+		m.viewport.SetContent(m.content())
+		return m, nil
+	case tea.KeyMsg, tea.MouseMsg:
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *Model) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = width - 2
+	m.viewport.Height = height - 4
+	m.viewport.SetContent(m.content())
+}
+
+func (m *Model) View() string {
+	failing := 0
+	for _, s := range m.statuses {
+		if s.Health() == mon.HealthFailing {
+			failing++
+		}
+	}
+	title := fmt.Sprintf(" Feeds (%d, %d failing)  f/esc: close ↑/↓: scroll", len(m.statuses), failing)
+	style := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#444444")).
+		Width(m.width - 2).
+		Height(m.height - 2)
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, title, m.header(), m.viewport.View()))
+}
+
+func (m *Model) header() string {
+	return m.row(
+		"",
+		headerStyle.Render("Feed"),
+		headerStyle.Render("Last success"),
+		headerStyle.Render("HTTP"),
+		headerStyle.Render("Fails"),
+		headerStyle.Render("Items"),
+		headerStyle.Render("Latency"),
+		headerStyle.Render("Next"),
+	)
+}
+
+func (m *Model) content() string {
+	if len(m.statuses) == 0 {
+		return dimStyle.Render("No feeds configured")
+	}
+	lines := make([]string, 0, len(m.statuses))
+	for _, s := range m.statuses {
+		title := s.Feed.Title
+		if title == "" {
+			title = s.Feed.Url
+		}
+		status := "-"
+		if s.LastStatus != 0 {
+			status = strconv.Itoa(s.LastStatus)
+		}
+		latency := "-"
+		if s.AvgLatency > 0 {
+			latency = s.AvgLatency.Round(time.Millisecond).String()
+		}
+		lines = append(lines, m.row(
+			healthStyle[s.Health()].Render("●"),
+			cellStyle.Render(title),
+			dimStyle.Render(since(s.LastSuccess)),
+			dimStyle.Render(status),
+			dimStyle.Render(strconv.Itoa(s.ConsecutiveFailures)),
+			dimStyle.Render(strconv.Itoa(s.ItemCount)),
+			dimStyle.Render(latency),
+			dimStyle.Render(until(s.NextFetch)),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) row(indicator, title, success, status, fails, items, latency, next string) string {
+	return grid.Render(grid.Grid{
+		Rows: []grid.Row{
+			{
+				Width: m.width - 2,
+				Cells: []grid.Cell{
+					{Text: indicator, Width: 2},
+					{Text: title, Overflow: grid.Hidden},
+					{Text: success, Width: 14},
+					{Text: status, Width: 6},
+					{Text: fails, Width: 6},
+					{Text: items, Width: 6},
+					{Text: latency, Width: 9},
+					{Text: next, Width: 9},
+				},
+			},
+		},
+	})
+}
+
+func since(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	diff := time.Since(t)
+	if diff < time.Minute {
+		return fmt.Sprintf("%ds ago", int(diff.Seconds()))
+	} else if diff < time.Hour {
+		return fmt.Sprintf("%dm ago", int(diff.Minutes()))
+	}
+	return fmt.Sprintf("%dh ago", int(diff.Hours()))
+}
+
+func until(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	diff := time.Until(t)
+	if diff <= 0 {
+		return "now"
+	} else if diff < time.Minute {
+		return fmt.Sprintf("in %ds", int(diff.Seconds()))
+	} else if diff < time.Hour {
+		return fmt.Sprintf("in %dm", int(diff.Minutes()))
+	}
+	return fmt.Sprintf("in %dh", int(diff.Hours()))
+}
@@ -0,0 +1,174 @@
+package news
+
+import (
+	"fmt"
+
+	c "nned/internal/common"
+	"nned/internal/ui/component/errlog"
+	"nned/internal/ui/component/news/row"
+	"nned/internal/ui/util"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	confirmThreshold = 50
+	undoDepth        = 10
+)
+
+var confirmStyle = util.NewStyle("#111111", "#FFAF00", true)
+
+// NoticeMsg reports the outcome of a bulk change to the footer.
+type NoticeMsg string
+
+// bulk is a change of the read state of several articles at once. Only
+// articles that were unread are recorded, so undoing it restores exactly
+// the previous state.
+type bulk struct {
+	description string
+	keys        []string
+}
+
+func (m *Model) markAllRead() tea.Cmd {
+	return m.markRead("shown articles", m.visible())
+}
+
+// markFeedRead marks every article of the feed under the cursor, or of the
+// section under the cursor when grouped by feed.
+func (m *Model) markFeedRead() tea.Cmd {
+	current := m.items[m.cursor]
+	if current.section != nil {
+		if m.mode != GroupByFeed {
+			return nil
+		}
+		articles := make([]*c.Article, 0)
+		for _, a := range m.articles {
+			if m.mode.sectionName(a) == current.section.name {
+				articles = append(articles, a)
+			}
+		}
+		return m.markRead(current.section.name, articles)
+	}
+
+	selected := current.article
+	articles := make([]*c.Article, 0)
+	for _, a := range m.articles {
+		if sameFeed(a, selected) {
+			articles = append(articles, a)
+		}
+	}
+	return m.markRead(GroupByFeed.sectionName(selected), articles)
+}
+
+// markOlderRead marks the shown articles published before the one under
+// the cursor.
+func (m *Model) markOlderRead() tea.Cmd {
+	selected := m.Selected()
+	if selected == nil || selected.Date == nil {
+		return nil
+	}
+	articles := make([]*c.Article, 0)
+	for _, a := range m.visible() {
+		if a.Date != nil && a.Date.Before(*selected.Date) {
+			articles = append(articles, a)
+		}
+	}
+	return m.markRead("articles older than "+selected.Date.Format("2006-01-02 15:04"), articles)
+}
+
+// markRead marks the unread ones of articles as read, after asking when
+// there are many of them.
+func (m *Model) markRead(description string, articles []*c.Article) tea.Cmd {
+	b := bulk{description: description}
+	for _, a := range articles {
+		key := articleKey(a)
+		if m.amap[key].Unread() {
+			b.keys = append(b.keys, key)
+		}
+	}
+	if len(b.keys) == 0 {
+		return notice("nothing to mark in " + description)
+	}
+	if len(b.keys) > confirmThreshold {
+		m.confirm = &b
+		return nil
+	}
+	return m.apply(b)
+}
+
+func (m *Model) apply(b bulk) tea.Cmd {
+	cmd := m.setReadMany(b.keys, true)
+	m.undo = append(m.undo, b)
+	if len(m.undo) > undoDepth {
+		m.undo = m.undo[1:]
+	}
+	return tea.Batch(cmd, notice(fmt.Sprintf("marked %d %s read (u: undo)", len(b.keys), b.description)))
+}
+
+func (m *Model) undoLast() tea.Cmd {
+	if len(m.undo) == 0 {
+		return notice("nothing to undo")
+	}
+	b := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+	cmd := m.setReadMany(b.keys, false)
+	return tea.Batch(cmd, notice(fmt.Sprintf("marked %d %s unread again", len(b.keys), b.description)))
+}
+
+func (m *Model) setReadMany(keys []string, read bool) tea.Cmd {
+	for _, key := range keys {
+		if r, ok := m.amap[key]; ok {
+			if read {
+				r.Update(row.SetReadMsg{})
+			} else {
+				r.Update(row.SetUnreadMsg{})
+			}
+		}
+	}
+	m.rebuild()
+	if m.store == nil {
+		return nil
+	}
+	err := m.store.SetReadMany(keys, read)
+	if err != nil {
+		return errlog.Report(err)
+	}
+	return nil
+}
+
+// updateConfirm answers the confirmation prompt of a large bulk change.
+func (m *Model) updateConfirm(msg tea.KeyMsg) tea.Cmd {
+	b := *m.confirm
+	switch msg.String() {
+	case "y", "Y", "enter":
+		m.confirm = nil
+		return m.apply(b)
+	case "n", "N", "esc", "q":
+		m.confirm = nil
+		return notice("cancelled")
+	}
+	return nil
+}
+
+// Confirming reports whether the list waits for a bulk change to be
+// confirmed, in which case it needs every key.
+func (m *Model) Confirming() bool {
+	return m.confirm != nil
+}
+
+func (m *Model) confirmPrompt() string {
+	return confirmStyle.Render(fmt.Sprintf(" mark %d %s read? (y/n) ", len(m.confirm.keys), m.confirm.description))
+}
+
+func sameFeed(a, b *c.Article) bool {
+	if a.FeedUrl != "" || b.FeedUrl != "" {
+		return a.FeedUrl == b.FeedUrl
+	}
+	return a.Source == b.Source
+}
+
+func notice(text string) tea.Cmd {
+	return func() tea.Msg {
+		return NoticeMsg(text)
+	}
+}
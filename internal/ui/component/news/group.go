@@ -0,0 +1,235 @@
+package news
+
+import (
+	"fmt"
+	"sort"
+
+	c "nned/internal/common"
+	"nned/internal/ui/component/news/row"
+	"nned/internal/ui/util"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type GroupMode int
+
+const (
+	GroupByDate GroupMode = iota
+	GroupByFeed
+	GroupByCategory
+)
+
+const uncategorized = "Uncategorized"
+
+var (
+	sectionStyle = util.NewStyle("#FFAF00", "", true)
+	countStyle   = util.NewStyle("#666666", "", false)
+)
+
+func (g GroupMode) String() string {
+	switch g {
+	case GroupByFeed:
+		return "feed"
+	case GroupByCategory:
+		return "category"
+	default:
+		return "date"
+	}
+}
+
+func (g GroupMode) grouped() bool {
+	return g != GroupByDate
+}
+
+func (g GroupMode) next() GroupMode {
+	return (g + 1) % 3
+}
+
+type section struct {
+	name      string
+	collapsed bool
+	total     int
+	unread    int
+}
+
+// item is one line of the news list: either a section header or an article.
+// Articles that belong to a story clustered from several feeds carry the key
+// of the story's first article; that article also counts the other sources.
+type item struct {
+	section *section
+	article *c.Article
+	row     *row.Model
+	story   string
+	sources int
+}
+
+func (i item) height() int {
+	if i.section != nil {
+		return sectionHeight
+	}
+	return rowHeight
+}
+
+func (i item) key() string {
+	if i.section != nil {
+		return "section:" + i.section.name
+	}
+	return articleKey(i.article)
+}
+
+func (g GroupMode) sectionName(a *c.Article) string {
+	switch g {
+	case GroupByFeed:
+		if a.SourceTitle != "" {
+			return a.SourceTitle
+		}
+		return a.Source
+	case GroupByCategory:
+		if a.Category != "" {
+			return a.Category
+		}
+		return uncategorized
+	}
+	return ""
+}
+
+// group lays out the visible articles for the current mode. Articles are
+// expected to be sorted by date; sections are sorted by name and keep that
+// order inside.
+func (m *Model) group(articles []*c.Article) []item {
+	items := make([]item, 0, len(articles))
+	if m.mode == GroupByDate {
+		return m.articleItems(articles)
+	}
+
+	sections := make(map[string]*section)
+	members := make(map[string][]*c.Article)
+	for _, a := range articles {
+		name := m.mode.sectionName(a)
+		s, ok := sections[name]
+		if !ok {
+			s = &section{
+				name:      name,
+				collapsed: m.collapsed[m.mode.String()+":"+name],
+			}
+			sections[name] = s
+		}
+		s.total++
+		if m.amap[articleKey(a)].Unread() {
+			s.unread++
+		}
+		members[name] = append(members[name], a)
+	}
+
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := sections[name]
+		items = append(items, item{section: s})
+		if s.collapsed {
+			continue
+		}
+		items = append(items, m.articleItems(members[name])...)
+	}
+	return items
+}
+
+// articleItems lays out articles one per row. With clustering enabled each
+// story gets a single row with a badge counting its other sources, which
+// are listed below it once the story is expanded.
+func (m *Model) articleItems(articles []*c.Article) []item {
+	items := make([]item, 0, len(articles))
+	if m.story == nil {
+		for _, a := range articles {
+			r := m.amap[articleKey(a)]
+			r.Update(row.SetBadgeMsg(""))
+			items = append(items, item{article: a, row: r})
+		}
+		return items
+	}
+
+	for _, story := range m.stories(articles) {
+		key := articleKey(story[0])
+		lead := item{article: story[0], row: m.amap[key]}
+		if len(story) == 1 {
+			lead.row.Update(row.SetBadgeMsg(""))
+			items = append(items, lead)
+			continue
+		}
+		lead.story = key
+		lead.sources = len(story) - 1
+		marker := "▸"
+		if m.expanded[key] {
+			marker = "▾"
+		}
+		lead.row.Update(row.SetBadgeMsg(fmt.Sprintf("+%d sources %s", lead.sources, marker)))
+		items = append(items, lead)
+		for _, a := range story[1:] {
+			r := m.amap[articleKey(a)]
+			r.Update(row.SetBadgeMsg(""))
+			if m.expanded[key] {
+				items = append(items, item{article: a, row: r, story: key})
+			}
+		}
+	}
+	return items
+}
+
+// stories groups the articles by story. Stories keep the order of their
+// articles and are ordered by their first one.
+func (m *Model) stories(articles []*c.Article) [][]*c.Article {
+	stories := make([][]*c.Article, 0, len(articles))
+	index := make(map[string]int)
+	for _, a := range articles {
+		story := m.story(articleKey(a))
+		n, ok := index[story]
+		if !ok {
+			n = len(stories)
+			index[story] = n
+			stories = append(stories, nil)
+		}
+		stories[n] = append(stories[n], a)
+	}
+	return stories
+}
+
+// toggleStory expands or collapses the story under the cursor. When it is
+// collapsed from one of its other sources the cursor moves to its first row.
+func (m *Model) toggleStory() {
+	current := m.items[m.cursor]
+	if current.story == "" {
+		return
+	}
+	m.expanded[current.story] = !m.expanded[current.story]
+	for i, it := range m.items {
+		if it.key() == current.story {
+			m.setCursor(i)
+			break
+		}
+	}
+	m.rebuild()
+}
+
+func (m *Model) toggleSection(s *section) {
+	key := m.mode.String() + ":" + s.name
+	m.collapsed[key] = !m.collapsed[key]
+}
+
+func (s *section) View(width int, selected bool) string {
+	marker := "▾"
+	if s.collapsed {
+		marker = "▸"
+	}
+	title := sectionStyle.Bold(!selected).Render(marker + " " + s.name)
+	count := countStyle.Render(fmt.Sprintf(" %d, %d unread", s.total, s.unread))
+	border := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#444444")).
+		BorderBottom(true).
+		Width(width)
+	return border.Render(title + count)
+}
@@ -2,11 +2,13 @@ package news
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 	"sync"
 
 	c "nned/internal/common"
+	"nned/internal/query"
+	"nned/internal/store"
+	"nned/internal/ui/component/errlog"
 	"nned/internal/ui/component/news/row"
 	"nned/internal/ui/util"
 
@@ -17,118 +19,483 @@ type SetArticlesMsg []c.Article
 
 type UpdateArticlesMsg []c.Article
 
+type SetFilterMsg query.Query
+
+// SetClusterMsg sets how stories covered by several feeds are found: the
+// function returns the key of the article leading the story of an article.
+// Nil lists every article on its own.
+type SetClusterMsg func(key string) string
+
+// SyncMsg refreshes the read and starred marks of every row from the
+// store, after they were changed in another list.
+type SyncMsg struct{}
+
+// StarMsg reports that the star of an article was toggled.
+type StarMsg struct {
+	Article c.Article
+	Starred bool
+}
+
+var modeStyle = util.NewStyle("#666666", "", false)
+
+const (
+	rowHeight     = 3
+	sectionHeight = 2
+	headerHeight  = 1
+)
+
 type Model struct {
-	width    int
-	cursor   int
-	articles []*c.Article
-	rows     []*row.Model
-	amap     map[uint64]c.Article
-	mu       sync.RWMutex
+	width     int
+	height    int
+	cursor    int
+	offset    int
+	articles  []*c.Article
+	items     []item
+	amap      map[string]*row.Model
+	mode      GroupMode
+	collapsed map[string]bool
+	filter    query.Query
+	story     func(key string) string
+	expanded  map[string]bool
+	confirm   *bulk
+	undo      []bulk
+	title     string
+	sorts     []SortMode
+	sort      int
+	text      map[string]string
+	store     *store.Store
+	mu        sync.RWMutex
 }
 
-func NewModel() *Model {
+func NewModel(st *store.Store) *Model {
 	return &Model{
-		width:    80,
-		articles: make([]*c.Article, 0),
-		amap:     make(map[uint64]c.Article),
-		cursor:   0,
+		width:     80,
+		height:    24,
+		articles:  make([]*c.Article, 0),
+		items:     make([]item, 0),
+		amap:      make(map[string]*row.Model),
+		collapsed: make(map[string]bool),
+		expanded:  make(map[string]bool),
+		text:      make(map[string]string),
+		store:     st,
+		cursor:    0,
+		title:     "news",
+		sorts:     []SortMode{SortNewest, SortOldest},
 	}
 }
+
+// NewSavedModel creates the list of starred articles, which is sorted by
+// the time they were starred first.
+func NewSavedModel(st *store.Store) *Model {
+	m := NewModel(st)
+	m.title = "saved"
+	m.sorts = []SortMode{SortSaved, SortNewest, SortOldest}
+	return m
+}
+
 func (m *Model) Init() tea.Cmd { return nil }
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
-	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case SetArticlesMsg:
-		cmds := make([]tea.Cmd, 0)
-		articles := make([]*c.Article, 0)
-
-		for i := range msg {
-			hash := util.GetHash(msg[i].Title + msg[i].Source)
-			if _, ok := m.amap[hash]; !ok {
-				articles = append(articles, &msg[i])
-				m.amap[hash] = msg[i]
-			}
+		if !m.add(msg) {
+			return m, nil
 		}
-
-		sort.Sort(c.ByDate(articles))
-
-		for i, article := range articles {
-			if i < len(m.rows) {
-				m.rows[i], cmd = m.rows[i].Update(row.UpdateArticleMsg(article))
-				cmds = append(cmds, cmd)
+		m.sortArticles()
+		m.rebuild()
+		return m, nil
+	case UpdateArticlesMsg:
+		m.articles = make([]*c.Article, 0, len(msg))
+		m.amap = make(map[string]*row.Model)
+		m.text = make(map[string]string)
+		m.add(msg)
+		m.sortArticles()
+		m.rebuild()
+		return m, nil
+	case SetFilterMsg:
+		m.filter = query.Query(msg)
+		m.rebuild()
+		return m, nil
+	case SyncMsg:
+		for key, r := range m.amap {
+			if m.isRead(key) {
+				r.Update(row.SetReadMsg{})
 			} else {
-				m.rows = append(m.rows, row.New(row.Config{
-					Article: article,
-					Width:   m.width,
-				}))
+				r.Update(row.SetUnreadMsg{})
 			}
+			r.Update(row.SetStarredMsg(m.isStarred(key)))
 		}
-
-		m.articles = articles
-		return m, tea.Batch(cmds...)
+		m.rebuild()
+		return m, nil
+	case SetClusterMsg:
+		m.story = msg
+		m.rebuild()
+		return m, nil
 	case tea.KeyMsg:
+		if m.confirm != nil {
+			return m, m.updateConfirm(msg)
+		}
+		if msg.String() == "u" {
+			return m, m.undoLast()
+		}
+		if len(m.items) == 0 {
+			return m, nil
+		}
 		switch msg.String() {
 		case "up":
-			prev_c := m.cursor
-			m.cursor--
-			if m.cursor < 0 {
-				m.cursor = 0
-			}
-			if prev_c != m.cursor {
-				m.rows[m.cursor], cmd = m.rows[m.cursor].Update(row.SetBoldMsg(true))
-				m.rows[prev_c], cmd = m.rows[prev_c].Update(row.SetBoldMsg(false))
-			}
+			m.setCursor(m.cursor - 1)
 			return m, nil
 		case "down":
-			prev_c := m.cursor
-			m.cursor++
-			if m.cursor > len(m.rows)-1 {
-				m.cursor = len(m.rows) - 1
+			m.setCursor(m.cursor + 1)
+			return m, nil
+		case "pgup":
+			m.setCursor(m.cursor - m.pageSize())
+			return m, nil
+		case "pgdown":
+			m.setCursor(m.cursor + m.pageSize())
+			return m, nil
+		case "home", "g":
+			m.setCursor(0)
+			return m, nil
+		case "end", "G":
+			m.setCursor(len(m.items) - 1)
+			return m, nil
+		case "enter", " ":
+			current := m.items[m.cursor]
+			if current.section != nil {
+				m.toggleSection(current.section)
+				m.rebuild()
+				return m, nil
 			}
-			if prev_c != m.cursor {
-				m.rows[m.cursor], cmd = m.rows[m.cursor].Update(row.SetBoldMsg(true))
-				m.rows[prev_c], cmd = m.rows[prev_c].Update(row.SetBoldMsg(false))
+			if msg.String() == " " {
+				return m, nil
 			}
-			return m, nil
-		case "enter":
-			m.rows[m.cursor], cmd = m.rows[m.cursor].Update(row.SetReadMsg{})
-			return m, cmd
+			current.row.Update(row.SetReadMsg{})
+			return m, m.setRead(current)
 		case "m":
-			m.rows[m.cursor], cmd = m.rows[m.cursor].Update(row.ToggleReadMsg{})
-			return m, cmd
+			current := m.items[m.cursor]
+			if current.section != nil {
+				return m, nil
+			}
+			current.row.Update(row.ToggleReadMsg{})
+			return m, m.setRead(current)
+		case "x":
+			m.toggleStory()
+			return m, nil
+		case "*":
+			return m, m.toggleStar()
+		case "r":
+			m.nextSort()
+			return m, nil
+		case "A":
+			return m, m.markAllRead()
+		case "F":
+			return m, m.markFeedRead()
+		case "O":
+			return m, m.markOlderRead()
+		case "v":
+			m.mode = m.mode.next()
+			m.rebuild()
+			return m, nil
 		}
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		for i, a := range m.rows {
-			m.rows[i], _ = a.Update(row.SetCellWidthMsg{
-				Width: msg.Width,
-			})
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
 		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.setCursor(m.cursor - 1)
+		case tea.MouseButtonWheelDown:
+			m.setCursor(m.cursor + 1)
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.SetDimensions(msg.Width, msg.Height)
 		return m, nil
 	case row.FrameMsg:
 		var cmd tea.Cmd
 		cmds := make([]tea.Cmd, 0)
-		for i, r := range m.rows {
-			m.rows[i], cmd = r.Update(msg)
+		for _, r := range m.amap {
+			_, cmd = r.Update(msg)
 			cmds = append(cmds, cmd)
 		}
 		return m, tea.Batch(cmds...)
 	}
-	fmt.Printf("%#v\n", msg)
 	return m, nil
 }
 
-func (m *Model) SetWidth(width int) {
-	m.width = width
+// add appends the articles that are not listed yet, replaces those that
+// were updated, and reports whether anything changed.
+func (m *Model) add(articles []c.Article) bool {
+	added := false
+	for i := range articles {
+		key := store.Key(articles[i])
+		if r, ok := m.amap[key]; ok {
+			if store.Replaces(articles[i], *r.Article()) {
+				*r.Article() = articles[i]
+				delete(m.text, key)
+				added = true
+			}
+			continue
+		}
+		article := articles[i]
+		m.amap[key] = row.New(row.Config{
+			Article: &article,
+			Width:   m.width,
+			Read:    m.isRead(key),
+			Starred: article.Starred || m.isStarred(key),
+		})
+		m.articles = append(m.articles, &article)
+		added = true
+	}
+	return added
+}
+
+// Selected returns the article under the cursor, or nil when the cursor is
+// on a section header.
+func (m *Model) Selected() *c.Article {
+	if m.cursor < 0 || m.cursor >= len(m.items) {
+		return nil
+	}
+	return m.items[m.cursor].article
+}
+
+func (m *Model) Mode() GroupMode {
+	return m.mode
+}
+
+// rebuild filters and lays the articles out again, then moves the cursor
+// back to the item it was on, or as close to its old position as possible
+// when that item is no longer shown.
+func (m *Model) rebuild() {
+	selected := ""
+	if m.cursor >= 0 && m.cursor < len(m.items) {
+		selected = m.items[m.cursor].key()
+	}
+
+	m.items = m.group(m.visible())
+
+	cursor := m.cursor
+	for i, it := range m.items {
+		if it.key() == selected {
+			cursor = i
+			break
+		}
+	}
+	m.cursor = -1
+	m.setCursor(cursor)
 }
 
+func (m *Model) setCursor(cursor int) {
+	if cursor > len(m.items)-1 {
+		cursor = len(m.items) - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	if m.cursor >= 0 && m.cursor < len(m.items) && m.items[m.cursor].row != nil {
+		m.items[m.cursor].row.Update(row.SetBoldMsg(false))
+	}
+	m.cursor = cursor
+	if m.cursor < len(m.items) && m.items[m.cursor].row != nil {
+		m.items[m.cursor].row.Update(row.SetBoldMsg(true))
+	}
+	m.scrollToCursor()
+}
+
+// scrollToCursor moves the window of rendered items so that the cursor
+// stays on screen.
+func (m *Model) scrollToCursor() {
+	if m.offset > m.cursor {
+		m.offset = m.cursor
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+	for m.offset < m.cursor && m.linesBetween(m.offset, m.cursor) > m.listHeight() {
+		m.offset++
+	}
+}
+
+func (m *Model) linesBetween(from, to int) int {
+	lines := 0
+	for i := from; i <= to && i < len(m.items); i++ {
+		lines += m.items[i].height()
+	}
+	return lines
+}
+
+func (m *Model) hasMoreBelow() bool {
+	lines := 0
+	for i := m.offset; i < len(m.items); i++ {
+		lines += m.items[i].height()
+		if lines > m.listHeight() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) listHeight() int {
+	return max(m.height-headerHeight, rowHeight)
+}
+
+func (m *Model) pageSize() int {
+	return max(m.listHeight()/rowHeight, 1)
+}
+
+func (m *Model) visible() []*c.Article {
+	if m.filter.Empty() {
+		return m.articles
+	}
+	articles := make([]*c.Article, 0)
+	for _, a := range m.articles {
+		key := articleKey(a)
+		if m.filter.Match(query.Target{
+			Article: a,
+			Text:    m.plainText(key, a),
+			Unread:  m.amap[key].Unread(),
+			Starred: m.amap[key].Starred(),
+		}) {
+			articles = append(articles, a)
+		}
+	}
+	return articles
+}
+
+func (m *Model) plainText(key string, a *c.Article) string {
+	text, ok := m.text[key]
+	if !ok {
+		text, _ = util.GetStringFromHTML(a.Description)
+		m.text[key] = text
+	}
+	return text
+}
+
+func (m *Model) isRead(key string) bool {
+	if m.store == nil {
+		return false
+	}
+	r, ok := m.store.Get(key)
+	return ok && r.Read
+}
+
+func (m *Model) isStarred(key string) bool {
+	if m.store == nil {
+		return false
+	}
+	r, ok := m.store.Get(key)
+	return ok && r.Starred
+}
+
+func (m *Model) toggleStar() tea.Cmd {
+	current := m.items[m.cursor]
+	if current.section != nil {
+		return nil
+	}
+	starred := !current.row.Starred()
+	current.row.Update(row.SetStarredMsg(starred))
+	current.article.Starred = starred
+	if m.filter.Starred() {
+		m.rebuild()
+	}
+	article := *current.article
+	star := func() tea.Msg {
+		return StarMsg{Article: article, Starred: starred}
+	}
+	if m.store == nil {
+		return star
+	}
+	err := m.store.SetStarred(articleKey(current.article), starred)
+	if err != nil {
+		return errlog.Report(err)
+	}
+	return star
+}
+
+func (m *Model) setRead(it item) tea.Cmd {
+	if m.mode != GroupByDate || !m.filter.Empty() {
+		m.rebuild()
+	}
+	if m.store == nil {
+		return nil
+	}
+	err := m.store.SetRead(articleKey(it.article), !it.row.Unread())
+	if err != nil {
+		return errlog.Report(err)
+	}
+	return nil
+}
+
+func (m *Model) SetDimensions(width, height int) {
+	m.height = height
+	if width != m.width {
+		m.width = width
+		for _, r := range m.amap {
+			r.Update(row.SetCellWidthMsg{
+				Width: width,
+			})
+		}
+	}
+	m.scrollToCursor()
+}
+
+// View renders only the items that fit between the offset and the bottom
+// of the list, so its cost does not grow with the number of articles.
 func (m *Model) View() string {
 	if m.width < 50 {
 		return "Terminal window too narrow to render news feed.\nResize to fix"
 	}
-	rows := make([]string, 0)
-	for _, row := range m.rows {
-		rows = append(rows, row.View())
+	lines := 0
+	rows := make([]string, 0, m.pageSize()+2)
+	if m.confirm != nil {
+		rows = append(rows, m.confirmPrompt())
+	} else {
+		rows = append(rows, modeStyle.Render(m.header()))
+	}
+	for i := m.offset; i < len(m.items); i++ {
+		it := m.items[i]
+		lines += it.height()
+		if lines > m.listHeight() {
+			break
+		}
+		if it.section != nil {
+			rows = append(rows, it.section.View(m.width, i == m.cursor))
+			continue
+		}
+		rows = append(rows, it.row.View())
 	}
 	return strings.Join(rows, "\n")
 }
+
+func (m *Model) header() string {
+	header := " " + m.title + ", " + m.sorts[m.sort].String() + " first, grouped by " + m.mode.String()
+	if !m.filter.Empty() {
+		header += fmt.Sprintf(", %d of %d shown", m.shown(), len(m.articles))
+	}
+	if len(m.items) > 0 {
+		header += fmt.Sprintf("  %d/%d", m.cursor+1, len(m.items))
+		if m.offset > 0 {
+			header += " ↑"
+		}
+		if m.hasMoreBelow() {
+			header += " ↓"
+		}
+	}
+	return header
+}
+
+func (m *Model) shown() int {
+	shown := 0
+	for _, it := range m.items {
+		if it.section != nil {
+			shown += it.section.total
+		} else if !m.mode.grouped() {
+			shown++
+		}
+	}
+	return shown
+}
+
+func articleKey(a *c.Article) string {
+	return store.Key(*a)
+}
@@ -2,6 +2,7 @@ package row
 
 import (
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -19,24 +20,32 @@ const (
 )
 
 var (
-	lastID      int64
-	titleStyle  = util.NewStyle("#EBEBEB", "", false)
-	unreadStyle = util.NewStyle("#FF0000", "", true)
-	timeStyle   = util.NewStyle("#666666", "", false)
+	lastID       int64
+	titleStyle   = util.NewStyle("#EBEBEB", "", false)
+	unreadStyle  = util.NewStyle("#FF0000", "", true)
+	starStyle    = util.NewStyle("#FFD700", "", true)
+	timeStyle    = util.NewStyle("#666666", "", false)
+	updatedStyle = util.NewStyle("#D7AF00", "", false)
+	badgeStyle   = util.NewStyle("#5FAFFF", "", false)
+	tagStyle     = util.NewStyle("#87AF87", "", false)
 )
 
 type Model struct {
-	id     int
-	width  int
-	config Config
-	bold   bool
-	unread bool
+	id      int
+	width   int
+	config  Config
+	bold    bool
+	unread  bool
+	starred bool
+	badge   string
 }
 
 type Config struct {
 	ID      int
 	Article *c.Article
 	Width   int
+	Read    bool
+	Starred bool
 }
 
 type UpdateArticleMsg *c.Article
@@ -50,10 +59,13 @@ type SetCellWidthMsg struct {
 type (
 	ToggleReadMsg struct{}
 	SetReadMsg    struct{}
+	SetUnreadMsg  struct{}
 )
 
 type (
-	SetBoldMsg bool
+	SetBoldMsg    bool
+	SetBadgeMsg   string
+	SetStarredMsg bool
 )
 
 func New(config Config) *Model {
@@ -65,11 +77,12 @@ func New(config Config) *Model {
 		id = nextID()
 	}
 	return &Model{
-		id:     id,
-		width:  config.Width,
-		config: config,
-		bold:   false,
-		unread: true,
+		id:      id,
+		width:   config.Width,
+		config:  config,
+		bold:    false,
+		unread:  !config.Read,
+		starred: config.Starred,
 	}
 }
 
@@ -84,8 +97,14 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		return m, nil
 	case SetBoldMsg:
 		m.bold = bool(msg)
+	case SetBadgeMsg:
+		m.badge = string(msg)
+	case SetStarredMsg:
+		m.starred = bool(msg)
 	case SetReadMsg:
 		m.unread = false
+	case SetUnreadMsg:
+		m.unread = true
 	case ToggleReadMsg:
 		m.unread = !m.unread
 		return m, nil
@@ -98,25 +117,57 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *Model) Article() *c.Article {
+	return m.config.Article
+}
+
+func (m *Model) Unread() bool {
+	return m.unread
+}
+
+func (m *Model) Starred() bool {
+	return m.starred
+}
+
 func (m *Model) View() string {
 	rows := []grid.Row{}
-	readStr := "•"
-	if !m.unread {
-		readStr = ""
+	title := titleStyle
+	if m.config.Article.Highlight != "" {
+		title = title.Foreground(lipgloss.Color(m.config.Article.Highlight))
+	}
+	readStr := " "
+	if m.unread {
+		readStr = "•"
+	}
+	starStr := " "
+	if m.starred {
+		starStr = "★"
 	}
 	rows = append(rows, grid.Row{
 		Width: m.width,
 		Cells: []grid.Cell{
-			{Text: titleStyle.Bold(!m.bold).Render(m.config.Article.Title), Width: m.width - 4, Overflow: grid.Hidden},
-			{Text: unreadStyle.Render(readStr), Width: 4},
+			{Text: title.Bold(!m.bold).Render(m.config.Article.Title), Width: m.width - 4, Overflow: grid.Hidden},
+			{Text: starStyle.Render(starStr) + " " + unreadStyle.Render(readStr), Width: 4},
 		},
 	})
 	time_s := timeAgo(m.config.Article.Date)
+	updated := ""
+	if m.config.Article.Updated != nil {
+		updated = updatedStyle.Render("  updated " + timeAgo(m.config.Article.Updated))
+	}
+	badge := ""
+	if m.badge != "" {
+		badge = badgeStyle.Render("  " + m.badge)
+	}
+	tags := ""
+	if len(m.config.Article.Tags) > 0 {
+		tags = tagStyle.Render("  #" + strings.Join(m.config.Article.Tags, " #"))
+	}
 	rows = append(rows, grid.Row{
 		Width: m.width,
 		Cells: []grid.Cell{
 			{Text: lipgloss.NewStyle().Background(lipgloss.Color(m.config.Article.SourceColor)).Render(m.config.Article.SourceTitle), Width: 10, Align: grid.Left, Overflow: grid.Hidden},
-			{Text: timeStyle.Render(time_s), Width: m.width - 10, Align: grid.Left},
+			{Text: timeStyle.Render(time_s) + updated + badge + tags, Width: m.width - 10, Align: grid.Left},
 		},
 	})
 
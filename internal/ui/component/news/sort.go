@@ -0,0 +1,65 @@
+package news
+
+import (
+	"sort"
+	"time"
+
+	c "nned/internal/common"
+)
+
+type SortMode int
+
+const (
+	SortNewest SortMode = iota
+	SortOldest
+	SortSaved
+)
+
+func (s SortMode) String() string {
+	switch s {
+	case SortOldest:
+		return "oldest"
+	case SortSaved:
+		return "saved"
+	default:
+		return "newest"
+	}
+}
+
+// sortArticles orders the articles by the current sort mode. Articles
+// without a date go last.
+func (m *Model) sortArticles() {
+	switch m.sorts[m.sort] {
+	case SortOldest:
+		sort.SliceStable(m.articles, func(i, j int) bool {
+			return before(m.articles[i].Date, m.articles[j].Date)
+		})
+	case SortSaved:
+		saved := make(map[*c.Article]time.Time, len(m.articles))
+		for _, a := range m.articles {
+			if r, ok := m.store.Get(articleKey(a)); ok {
+				saved[a] = r.StarredAt
+			}
+		}
+		sort.SliceStable(m.articles, func(i, j int) bool {
+			return saved[m.articles[i]].After(saved[m.articles[j]])
+		})
+	default:
+		sort.SliceStable(m.articles, func(i, j int) bool {
+			return before(m.articles[j].Date, m.articles[i].Date)
+		})
+	}
+}
+
+func (m *Model) nextSort() {
+	m.sort = (m.sort + 1) % len(m.sorts)
+	m.sortArticles()
+	m.rebuild()
+}
+
+func before(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+	return a.Before(*b)
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"nned/internal/query"
+	"nned/internal/ui/util"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	promptStyle = util.NewStyle("#FFAF00", "", true)
+	filterStyle = util.NewStyle("#999999", "", false)
+	errorStyle  = util.NewStyle("#FF5F5F", "", false)
+)
+
+// QueryMsg carries a new valid query to apply to the news list.
+type QueryMsg struct {
+	Query query.Query
+}
+
+type Model struct {
+	input textinput.Model
+	err   error
+	width int
+}
+
+func NewModel() *Model {
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.PromptStyle = promptStyle
+	input.Placeholder = `words "a phrase" feed: cat: is:unread after:2024-01-01 -exclude`
+	return &Model{
+		input: input,
+		width: 80,
+	}
+}
+
+func (m *Model) Init() tea.Cmd { return nil }
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			m.input.Blur()
+			return m, nil
+		case "esc":
+			m.input.Blur()
+			m.input.SetValue("")
+			m.err = nil
+			return m, apply(query.Query{})
+		}
+		previous := m.input.Value()
+		m.input, cmd = m.input.Update(msg)
+		if m.input.Value() == previous {
+			return m, cmd
+		}
+		q, err := query.Parse(m.input.Value())
+		m.err = err
+		if err != nil {
+			return m, cmd
+		}
+		return m, tea.Batch(cmd, apply(q))
+	}
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) Focus() tea.Cmd {
+	return m.input.Focus()
+}
+
+// Active reports whether the search input has the keyboard focus.
+func (m *Model) Active() bool {
+	return m.input.Focused()
+}
+
+// Visible reports whether the search bar should be drawn, either because
+// it is being edited or because a filter is applied.
+func (m *Model) Visible() bool {
+	return m.Active() || m.input.Value() != ""
+}
+
+func (m *Model) SetWidth(width int) {
+	m.width = width
+	m.input.Width = width - 4
+}
+
+func (m *Model) View() string {
+	if m.Active() {
+		view := m.input.View()
+		if m.err != nil {
+			view += " " + errorStyle.Render(m.err.Error())
+		}
+		return view
+	}
+	return filterStyle.Render("filter: " + m.input.Value() + "  (/: edit esc: clear)")
+}
+
+func apply(q query.Query) tea.Cmd {
+	return func() tea.Msg {
+		return QueryMsg{Query: q}
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+type helpSection struct {
+	title string
+	keys  [][2]string
+}
+
+var helpSections = []helpSection{
+	{"List", [][2]string{
+		{"↑/↓", "select article"},
+		{"enter", "read article"},
+		{"tab", "focus the reader"},
+		{"s, /", "search"},
+		{"m", "mark read/unread"},
+		{"A / F / O", "mark shown / feed / older read"},
+		{"u", "undo bulk change"},
+		{"*", "save article"},
+		{"S", "switch between news and saved"},
+		{"r", "sort order"},
+		{"v", "grouping"},
+		{"x", "show sources of a story"},
+		{"o", "open in browser"},
+		{"y", "copy link"},
+	}},
+	{"Reader", [][2]string{
+		{"↑/↓, j/k", "scroll"},
+		{"space, f / b", "page down / up"},
+		{"d / u", "half page down / up"},
+		{"g / G", "top / bottom"},
+		{"tab", "back to the list"},
+	}},
+	{"Everywhere", [][2]string{
+		{"e", "errors"},
+		{"f", "feeds (from the list)"},
+		{"?", "this help"},
+		{"esc", "close overlay"},
+		{"q", "exit"},
+	}},
+}
+
+// helpView lists every key binding for the help overlay.
+func helpView() string {
+	var sb strings.Builder
+	for _, section := range helpSections {
+		sb.WriteString("\n " + styleLogo.Render(" "+section.title+" ") + "\n\n")
+		for _, key := range section.keys {
+			fmt.Fprintf(&sb, "  %-14s %s\n", key[0], styleHelp.Render(key[1]))
+		}
+	}
+	return sb.String()
+}
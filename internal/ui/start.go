@@ -1,28 +1,84 @@
 package ui
 
 import (
+	"fmt"
+	"os"
+	"sync"
+
 	c "nned/internal/common"
 	mon "nned/internal/monitor"
+	feedscraper "nned/internal/monitor/feed-scraper"
+	"nned/internal/monitor/fulltext"
+	"nned/internal/notify"
+	"nned/internal/rules"
+	"nned/internal/store"
+	"nned/internal/ui/component/errlog"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func Start(dep *c.Dependencies, ctx *c.Context) func() error {
+// output is the terminal the program renders to. Notifications and the
+// clipboard copy write their escape sequences to it too, so writes are
+// serialized to keep them from landing in the middle of a frame.
+type output struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+func (o *output) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// ConfigWatcher reports changes of the config file until the returned
+// function is called.
+type ConfigWatcher func(onChange func(c.Config), onError func(error)) (func() error, error)
+
+func Start(dep *c.Dependencies, ctx *c.Context, watch ConfigWatcher) func() error {
 	return func() error {
+		st, err := store.Open(dep.Fs, store.DefaultPath(), ctx.Config.LastDate)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		engine, err := rules.Compile(ctx.Config.Rules)
+		if err != nil {
+			return err
+		}
+
+		out := &output{File: os.Stdout}
+		notifier, err := notify.New(ctx.Config.Notify, out)
+		if err != nil {
+			return err
+		}
+		defer notifier.Close()
+
 		monitor, _ := mon.NewMonitor(mon.Config{
 			RefreshInterval: ctx.Config.RefreshInterval,
+			MaxBackoff:      ctx.Config.MaxBackoff,
 			Feeds:           ctx.Config.NewsFeeds,
 			LastDate:        ctx.Config.LastDate,
+			Store:           st,
+			Rules:           engine,
+			Cluster:         ctx.Config.Cluster,
+			Fs:              dep.Fs,
+			CachePath:       feedscraper.DefaultCachePath(),
+			FullTextDir:     fulltext.DefaultCacheDir(),
 		})
 
 		p := tea.NewProgram(
-			NewModel(*dep, *ctx, monitor),
+			NewModel(*dep, *ctx, monitor, st, out),
 			tea.WithMouseCellMotion(),
 			tea.WithAltScreen(),
+			tea.WithOutput(out),
 		)
 
-		var err error
-
 		err = monitor.SetOnUpdate(mon.ConfigUpdateFunc{
 			OnUpdateArticle: func(article c.Article, versionVector int) {
 				p.Send(SetArticleMsg{
@@ -30,10 +86,36 @@ func Start(dep *c.Dependencies, ctx *c.Context) func() error {
 					versionVector: versionVector,
 				})
 			},
+			OnError: func(err error) {
+				p.Send(errlog.ErrorMsg{Err: err})
+			},
+			OnNotify: notifier.Add,
 		})
 		if err != nil {
 			return err
 		}
+		notifier.SetOnError(func(err error) {
+			p.Send(errlog.ErrorMsg{Err: err})
+		})
+
+		if watch != nil {
+			stop, err := watch(
+				func(config c.Config) {
+					err := notifier.Configure(config.Notify)
+					if err != nil {
+						p.Send(errlog.ErrorMsg{Err: fmt.Errorf("notifications not reloaded: %w", err)})
+					}
+					p.Send(ConfigMsg{config: config})
+				},
+				func(err error) {
+					p.Send(errlog.ErrorMsg{Err: err})
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("unable to watch config: %w", err)
+			}
+			defer stop()
+		}
 
 		_, err = p.Run()
 
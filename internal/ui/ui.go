@@ -2,37 +2,57 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"slices"
 	"sync"
 	"time"
 
 	"nned/internal/ui/component/article"
+	"nned/internal/ui/component/errlog"
+	"nned/internal/ui/component/feeds"
 	"nned/internal/ui/component/news"
 	"nned/internal/ui/component/news/row"
+	"nned/internal/ui/component/search"
 	"nned/internal/ui/util"
 
 	c "nned/internal/common"
 	mon "nned/internal/monitor"
+	"nned/internal/rules"
+	"nned/internal/store"
 
 	grid "github.com/achannarasappa/term-grid"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
 )
 
 type Model struct {
 	articles       []c.Article
+	seen           map[string]bool
 	news           *news.Model
+	inbox          *news.Model
+	saved          *news.Model
+	view           view
 	article        *article.Model
-	cursor         int
+	errlog         *errlog.Model
+	feeds          *feeds.Model
+	search         *search.Model
+	searches       [2]*search.Model
+	overlay        overlay
 	ctx            c.Context
 	viewport       viewport.Model
 	ready          bool
 	lastUpdateTime string
 	headerHeight   int
 	monitor        *mon.Monitor
+	store          *store.Store
+	out            io.Writer
 	mu             sync.RWMutex
 	versionVector  int
+	notice         string
+	noticeID       int
+	lastSync       time.Time
 }
 
 type SetArticleMsg struct {
@@ -40,31 +60,165 @@ type SetArticleMsg struct {
 	versionVector int
 }
 
+// ConfigMsg carries a config that was changed while nned is running.
+type ConfigMsg struct {
+	config c.Config
+}
+
 type tickMsg struct {
 	versionVector int
 }
 
+type clearNoticeMsg struct {
+	id int
+}
+
+// view is a list of articles with its own search. The news list is shown
+// in m.news, while the saved articles are kept apart.
+type view int
+
+const (
+	viewNews view = iota
+	viewSaved
+)
+
+type overlay int
+
+const (
+	overlayNone overlay = iota
+	overlayErrors
+	overlayFeeds
+	overlayHelp
+)
+
 var (
-	styleLogo = util.NewStyle("#111111", "#ff8700", true)
-	styleHelp = util.NewStyle("#4e4e4e", "", false)
+	styleLogo   = util.NewStyle("#111111", "#ff8700", true)
+	styleHelp   = util.NewStyle("#4e4e4e", "", false)
+	styleError  = util.NewStyle("#FF5F5F", "", false)
+	styleNotice = util.NewStyle("#5FD75F", "", false)
 )
 
 const (
 	footerHeight   = 1
 	minFooterWidth = 80
+
+	storeSyncInterval = 2 * time.Second
 )
 
-func NewModel(dep c.Dependencies, ctx c.Context, monitor *mon.Monitor) *Model {
-	return &Model{
+// NewModel creates the root model. Escape sequences the model emits itself,
+// such as the clipboard copy, are written to out, the program's output.
+func NewModel(dep c.Dependencies, ctx c.Context, monitor *mon.Monitor, st *store.Store, out io.Writer) *Model {
+	m := &Model{
 		articles:     make([]c.Article, 0),
-		cursor:       0,
+		seen:         make(map[string]bool),
 		ctx:          ctx,
 		ready:        false,
-		news:         news.NewModel(),
+		inbox:        news.NewModel(st),
+		saved:        news.NewSavedModel(st),
 		article:      article.NewModel(),
+		errlog:       errlog.NewModel(),
+		feeds:        feeds.NewModel(),
+		searches:     [2]*search.Model{search.NewModel(), search.NewModel()},
 		headerHeight: 0,
 		monitor:      monitor,
+		store:        st,
+		out:          out,
+	}
+	m.news = m.inbox
+	m.search = m.searches[viewNews]
+	m.inbox.Update(m.clusterMsg())
+	m.loadStored()
+	m.loadSaved()
+	return m
+}
+
+// loadStored fills the article list with the stored articles the monitor
+// would show under the current config.
+func (m *Model) loadStored() {
+	for _, a := range m.monitor.StoredArticles() {
+		m.addArticle(a)
 	}
+	slices.SortFunc(m.articles, util.DateCmp)
+}
+
+// loadSaved fills the saved list with every starred article in the store.
+// Unlike the news list it ignores the last date and the configured feeds,
+// since saved articles are kept for good.
+func (m *Model) loadSaved() {
+	saved := make([]c.Article, 0)
+	for _, r := range m.store.Records() {
+		if r.Starred {
+			a := r.Article
+			a.Starred = true
+			saved = append(saved, a)
+		}
+	}
+	m.saved.Update(news.UpdateArticlesMsg(saved))
+}
+
+// syncStore picks up the read and starred marks that other processes, such
+// as nned serve, saved to the store.
+func (m *Model) syncStore() tea.Cmd {
+	if time.Since(m.lastSync) < storeSyncInterval {
+		return nil
+	}
+	m.lastSync = time.Now()
+	changed, err := m.store.Reload()
+	if err != nil {
+		return errlog.Report(err)
+	}
+	if changed {
+		m.inbox.Update(news.SyncMsg{})
+		m.loadSaved()
+	}
+	return nil
+}
+
+func (m *Model) clusterMsg() news.SetClusterMsg {
+	return m.monitor.Stories()
+}
+
+// switchView toggles between the news and the saved list. Each keeps its own
+// search, sort and cursor.
+func (m *Model) switchView() {
+	m.view = (m.view + 1) % 2
+	m.news = m.inbox
+	if m.view == viewSaved {
+		m.news = m.saved
+	}
+	m.search = m.searches[m.view]
+	m.news.Update(news.SyncMsg{})
+}
+
+// reload applies a changed config. The monitor starts over with the new
+// feeds, and articles still arriving for the old ones are dropped by their
+// version vector.
+func (m *Model) reload(config c.Config) tea.Cmd {
+	engine, err := rules.Compile(config.Rules)
+	if err != nil {
+		return errlog.Report(fmt.Errorf("config not reloaded: %w", err))
+	}
+	m.ctx.Config = config
+	m.versionVector = m.monitor.Reconfigure(mon.Config{
+		RefreshInterval: config.RefreshInterval,
+		MaxBackoff:      config.MaxBackoff,
+		Feeds:           config.NewsFeeds,
+		LastDate:        config.LastDate,
+		Rules:           engine,
+		Cluster:         config.Cluster,
+	})
+	m.articles = make([]c.Article, 0)
+	m.seen = make(map[string]bool)
+	m.loadStored()
+
+	var cmd tea.Cmd
+	m.inbox.Update(m.clusterMsg())
+	m.inbox, cmd = m.inbox.Update(news.UpdateArticlesMsg(m.articles))
+	m.loadSaved()
+	if m.overlay == overlayFeeds {
+		m.feeds, _ = m.feeds.Update(feeds.SetStatusMsg(m.monitor.FeedStatus()))
+	}
+	return tea.Batch(cmd, m.setNotice("config reloaded"))
 }
 
 func (m *Model) Init() tea.Cmd {
@@ -82,32 +236,78 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.overlay != overlayNone {
+			return m.updateOverlay(msg)
+		}
+		if m.search.Active() {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.search, cmd = m.search.Update(msg)
+			return m, cmd
+		}
+		if m.news.Confirming() && msg.String() != "ctrl+c" {
+			m.news, cmd = m.news.Update(msg)
+			return m, cmd
+		}
+		// The reader takes its scroll keys before the global ones while it
+		// has the focus, so f and u page instead of opening the feeds or
+		// undoing.
+		if m.article.Focused() {
+			switch msg.String() {
+			case "up", "down", "pgup", "pgdown", "home", "end", "g", "G", " ", "f", "b", "u", "d", "j", "k":
+				m.article, cmd = m.article.Update(msg)
+				return m, cmd
+			}
+		}
 		switch msg.String() {
-		case "ctrl+c":
-			fallthrough
 		case "esc":
+			if m.search.Visible() {
+				m.search, cmd = m.search.Update(msg)
+				return m, cmd
+			}
+			return m, tea.Quit
+		case "ctrl+c":
 			fallthrough
 		case "q":
 			return m, tea.Quit
+		case "s", "/":
+			return m, m.search.Focus()
+		case "S":
+			m.switchView()
+			return m, nil
+		case "e":
+			m.overlay = overlayErrors
+			return m, nil
+		case "?":
+			m.overlay = overlayHelp
+			return m, nil
+		case "f":
+			m.overlay = overlayFeeds
+			m.feeds, cmd = m.feeds.Update(feeds.SetStatusMsg(m.monitor.FeedStatus()))
+			return m, cmd
+		case "tab":
+			m.article.SetFocus(!m.article.Focused())
+			return m, nil
+		case "o":
+			return m, m.openSelected()
+		case "y":
+			return m, m.copySelected()
+		}
+		switch msg.String() {
 		case "up":
-			m.cursor--
-			if m.cursor < 0 {
-				m.cursor = 0
-			}
 			m.news, cmd = m.news.Update(msg)
 			return m, cmd
 		case "down":
-			m.cursor++
-			if m.cursor > len(m.articles)-1 {
-				m.cursor = len(m.articles) - 1
-			}
 			m.news, cmd = m.news.Update(msg)
 			return m, cmd
 		case "enter":
-			m.article, cmd = m.article.Update(article.SetArticleMsg(&m.articles[m.cursor]))
+			if selected := m.news.Selected(); selected != nil {
+				m.article, cmd = m.article.Update(article.SetArticleMsg(selected))
+			}
 			m.news, cmd = m.news.Update(msg)
 			return m, cmd
-		case "m":
+		case "m", "v", "x", "*", "r", "A", "F", "O", "u", " ", "pgup", "pgdown", "home", "end", "g", "G":
 			m.news, cmd = m.news.Update(msg)
 			return m, cmd
 		}
@@ -118,20 +318,75 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if !m.ready {
 			m.viewport = viewport.New(msg.Width, viewportHeight)
 			m.ready = true
-			m.news.SetWidth(msg.Width / 2)
-			m.article.SetDimensions(msg.Width/2, viewportHeight)
 		} else {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = viewportHeight
 		}
+		for _, input := range m.searches {
+			input.SetWidth(msg.Width / 2)
+		}
+		m.article.SetDimensions(msg.Width/2, viewportHeight)
+		m.errlog.SetDimensions(msg.Width, viewportHeight)
+		m.feeds.SetDimensions(msg.Width, viewportHeight)
+		return m, cmd
+
+	case tea.MouseMsg:
+		switch m.overlay {
+		case overlayErrors:
+			m.errlog, cmd = m.errlog.Update(msg)
+		case overlayFeeds:
+			m.feeds, cmd = m.feeds.Update(msg)
+		default:
+			if msg.X >= m.viewport.Width/2 {
+				m.article, cmd = m.article.Update(msg)
+			} else {
+				m.news, cmd = m.news.Update(msg)
+			}
+		}
+		return m, cmd
+
+	case ConfigMsg:
+		return m, m.reload(msg.config)
+
+	case news.StarMsg:
+		m.loadSaved()
+		if m.view == viewSaved {
+			m.inbox.Update(news.SyncMsg{})
+		}
+		if msg.Starred {
+			return m, m.setNotice("saved " + msg.Article.Title + " (S: saved articles)")
+		}
+		return m, nil
+
+	case news.NoticeMsg:
+		return m, m.setNotice(string(msg))
+
+	case clearNoticeMsg:
+		if msg.id == m.noticeID {
+			m.notice = ""
+		}
+		return m, nil
+
+	case search.QueryMsg:
+		m.news, cmd = m.news.Update(news.SetFilterMsg(msg.Query))
+		return m, cmd
+
+	case errlog.ErrorMsg:
+		m.errlog, cmd = m.errlog.Update(msg)
 		return m, cmd
 
 	case tickMsg:
 		cmds := make([]tea.Cmd, 0)
 
-		m.news, cmd = m.news.Update(news.SetArticlesMsg(m.articles))
+		m.inbox, cmd = m.inbox.Update(news.SetArticlesMsg(m.articles))
 		cmds = append(cmds, cmd)
 		m.lastUpdateTime = getTime()
+		cmds = append(cmds, m.syncStore())
+
+		if m.overlay == overlayFeeds {
+			m.feeds, cmd = m.feeds.Update(feeds.SetStatusMsg(m.monitor.FeedStatus()))
+			cmds = append(cmds, cmd)
+		}
 
 		if m.ready {
 			m.viewport, cmd = m.viewport.Update(msg)
@@ -144,8 +399,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		m.articles = append(m.articles, msg.article)
-		slices.SortFunc(m.articles, util.DateCmp)
+		if m.addArticle(msg.article) {
+			slices.SortFunc(m.articles, util.DateCmp)
+		}
 		return m, nil
 
 	case row.FrameMsg:
@@ -153,6 +409,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.news, cmd = m.news.Update(msg)
 		return m, cmd
 	}
+	if m.search.Active() {
+		m.search, cmd = m.search.Update(msg)
+		return m, cmd
+	}
 	return m, nil
 }
 
@@ -161,11 +421,123 @@ func (m *Model) View() string {
 		return "\n Fetching articles..."
 	}
 	content := "Loading content..."
-	content = lipgloss.JoinHorizontal(lipgloss.Top, m.news.View(), m.article.View())
+	switch m.overlay {
+	case overlayErrors:
+		content = m.errlog.View()
+	case overlayFeeds:
+		content = m.feeds.View()
+	case overlayHelp:
+		content = helpView()
+	default:
+		listHeight := m.viewport.Height
+		if m.search.Visible() {
+			listHeight--
+		}
+		m.news.SetDimensions(m.viewport.Width/2, listHeight)
+		list := m.news.View()
+		if m.search.Visible() {
+			list = lipgloss.JoinVertical(lipgloss.Left, m.search.View(), list)
+		}
+		content = lipgloss.JoinHorizontal(lipgloss.Top, list, m.article.View())
+	}
 	m.viewport.SetContent(content)
 
 	return m.viewport.View() + "\n" +
-		footer(m.viewport.Width, m.lastUpdateTime)
+		footer(m.viewport.Width, m.lastUpdateTime, m.help(), m.status())
+}
+
+func (m *Model) updateOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc":
+		m.overlay = overlayNone
+		return m, nil
+	case "e":
+		m.overlay = toggle(m.overlay, overlayErrors)
+		return m, nil
+	case "?":
+		m.overlay = toggle(m.overlay, overlayHelp)
+		return m, nil
+	case "f":
+		m.overlay = toggle(m.overlay, overlayFeeds)
+		m.feeds, cmd = m.feeds.Update(feeds.SetStatusMsg(m.monitor.FeedStatus()))
+		return m, cmd
+	}
+	switch m.overlay {
+	case overlayErrors:
+		m.errlog, cmd = m.errlog.Update(msg)
+	case overlayFeeds:
+		m.feeds, cmd = m.feeds.Update(msg)
+	}
+	return m, cmd
+}
+
+func toggle(current, o overlay) overlay {
+	if current == o {
+		return overlayNone
+	}
+	return o
+}
+
+func (m *Model) openSelected() tea.Cmd {
+	selected := m.news.Selected()
+	if selected == nil || selected.Link == "" {
+		return nil
+	}
+	err := util.OpenURL(m.ctx.Config.Browser, selected.Link)
+	if err != nil {
+		return errlog.Report(fmt.Errorf("unable to open browser: %w", err))
+	}
+	return m.setNotice("opened " + selected.Link)
+}
+
+func (m *Model) copySelected() tea.Cmd {
+	selected := m.news.Selected()
+	if selected == nil || selected.Link == "" {
+		return nil
+	}
+	err := util.CopyToClipboard(m.out, selected.Link)
+	if err != nil {
+		return errlog.Report(fmt.Errorf("unable to copy link: %w", err))
+	}
+	return m.setNotice("copied " + selected.Link)
+}
+
+// setNotice shows a short lived message in the footer.
+func (m *Model) setNotice(notice string) tea.Cmd {
+	m.notice = notice
+	m.noticeID++
+	id := m.noticeID
+	return tea.Tick(3*time.Second, func(time.Time) tea.Msg {
+		return clearNoticeMsg{id: id}
+	})
+}
+
+func (m *Model) status() string {
+	if m.notice != "" {
+		return styleNotice.Render(m.notice)
+	}
+	return styleError.Render(m.errlog.Status())
+}
+
+// addArticle adds a new article, or replaces the listed one when a is a
+// later version of it. It reports whether the list changed.
+func (m *Model) addArticle(a c.Article) bool {
+	key := store.Key(a)
+	if !m.seen[key] {
+		m.seen[key] = true
+		m.articles = append(m.articles, a)
+		return true
+	}
+	for i := range m.articles {
+		if store.Key(m.articles[i]) == key && store.Replaces(a, m.articles[i]) {
+			m.articles[i] = a
+			return true
+		}
+	}
+	return false
 }
 
 func tick(t int) tea.Cmd {
@@ -176,19 +548,36 @@ func tick(t int) tea.Cmd {
 	})
 }
 
-func footer(width int, time string) string {
+// help is the hint for the focused pane; the full list of keys is behind ?.
+// Some keys, such as f and u, do different things in the list and in the
+// reader.
+func (m *Model) help() string {
+	if m.article.Focused() {
+		return "reader  tab: list  ?: help"
+	}
+	return "q: exit  enter: read  s: search  ?: help"
+}
+
+// footer gives the status whatever width the logo, the hint and the time
+// leave. The status is truncated here rather than by the grid, which would
+// cut through its escape sequences.
+func footer(width int, time string, help string, status string) string {
 	if width < minFooterWidth {
 		return "nned"
 	}
-	help := "q: exit ↑: scroll up ↓: scroll down s: search m: mark read/unread enter: read article"
+	logo := styleLogo.Render(" nned ")
+	hint := styleHelp.Render(help + " ")
+	clock := styleHelp.Render("T: " + time)
+	available := width - lipgloss.Width(logo) - lipgloss.Width(hint) - 16
 	return grid.Render(grid.Grid{
 		Rows: []grid.Row{
 			{
 				Width: width,
 				Cells: []grid.Cell{
-					{Text: styleLogo.Render(" nned "), Width: 7},
-					{Text: styleHelp.Render(help), Width: len(help)},
-					{Text: styleHelp.Render("T: " + time), Align: grid.Right},
+					{Text: logo, Width: lipgloss.Width(logo)},
+					{Text: hint, Width: lipgloss.Width(hint)},
+					{Text: truncate.StringWithTail(status, uint(max(available, 0)), "…")},
+					{Text: clock, Width: 16, Align: grid.Right},
 				},
 			},
 		},
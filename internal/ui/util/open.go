@@ -0,0 +1,94 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+const defaultBrowser = "xdg-open"
+
+// BrowserCommand builds the command that opens url. The configured command
+// wins over $BROWSER, which wins over xdg-open. A "%s" in the command is
+// replaced by the url, otherwise the url is appended. Links come from the
+// feeds, so only http and https links are opened.
+func BrowserCommand(configured string, url string) (*exec.Cmd, error) {
+	err := validateLink(url)
+	if err != nil {
+		return nil, err
+	}
+
+	command := configured
+	if command == "" {
+		command = os.Getenv("BROWSER")
+	}
+	if command == "" {
+		command = defaultBrowser
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty browser command")
+	}
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, "%s") {
+			args[i] = strings.ReplaceAll(arg, "%s", url)
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, url)
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// validateLink rejects links that are not web pages, and those starting
+// with "-" that the browser would take for an option.
+func validateLink(link string) error {
+	if strings.HasPrefix(strings.TrimSpace(link), "-") {
+		return fmt.Errorf("refusing to open %q", link)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("invalid link %q: %w", link, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("refusing to open %q: only http and https links are opened", link)
+	}
+	return nil
+}
+
+// OpenURL starts the browser in the background without waiting for it.
+func OpenURL(configured string, url string) error {
+	cmd, err := BrowserCommand(configured, url)
+	if err != nil {
+		return err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// CopyToClipboard writes text to the system clipboard with an OSC52 escape
+// sequence, which terminals honour over SSH as well. The sequence is
+// wrapped for tmux and screen when running inside them.
+func CopyToClipboard(w io.Writer, text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(w)
+	return err
+}